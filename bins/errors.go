@@ -0,0 +1,40 @@
+package bins
+
+import "errors"
+
+// The kinds of error returned by every DB method. Match them with errors.Is,
+// the underlying cause stays available to errors.As.
+var (
+	ErrNotFound    = errors.New("bin not found")
+	ErrConflict    = errors.New("bin conflicts with an existing bin")
+	ErrValidation  = errors.New("bin is invalid")
+	ErrUnavailable = errors.New("bin storage unavailable")
+)
+
+// Error is a repository failure of one of the kinds above
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Wrap classifies err as kind. A nil err stays nil.
+func Wrap(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the kind of the error
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
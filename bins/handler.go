@@ -0,0 +1,18 @@
+package bins
+
+import (
+	"context"
+
+	"github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+)
+
+// Handler ...
+type Handler interface {
+	GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error)
+	Get(ctx context.Context, accountID string, ID string) (*models.Bin, error)
+	Create(ctx context.Context, bin *models.Bin) (string, error)
+	Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error)
+	Delete(ctx context.Context, accountID string, ID string) (int, error)
+	Destroy(ctx context.Context, accountID string) (int, error)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	gModels "github.com/hugocortes/hooks-api/models"
+)
+
+// Handler provides the bin use cases
+type Handler struct {
+	repo *bins.Repository
+}
+
+// New ...
+func New(repo *bins.Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// GetAll returns a page of bins for the account
+func (h *Handler) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	return h.repo.DB.GetAll(ctx, accountID, opts)
+}
+
+// Get returns the bin or bins.ErrNotFound if the account has no such bin
+func (h *Handler) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	return h.repo.DB.Get(ctx, accountID, ID)
+}
+
+// Create stores the bin under bin.AccountID and returns the new bin ID
+func (h *Handler) Create(ctx context.Context, bin *models.Bin) (string, error) {
+	if err := h.repo.DB.Create(ctx, bin.AccountID, bin); err != nil {
+		return "", err
+	}
+
+	return bin.ID, nil
+}
+
+// Update returns the number of updated bins. The scenario of an updated bin
+// starts over. bins.ErrNotFound is returned if the account has no such bin.
+func (h *Handler) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	affected, err := h.repo.DB.Update(ctx, accountID, ID, bin)
+	if err == nil {
+		h.resetSequence(ctx, ID)
+	}
+
+	return affected, err
+}
+
+// Delete returns the number of deleted bins or bins.ErrNotFound if the
+// account has no such bin
+func (h *Handler) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	affected, err := h.repo.DB.Delete(ctx, accountID, ID)
+	if err == nil {
+		h.resetSequence(ctx, ID)
+	}
+
+	return affected, err
+}
+
+// Destroy removes every bin of the account and returns the number deleted
+func (h *Handler) Destroy(ctx context.Context, accountID string) (int, error) {
+	return h.repo.DB.Destroy(ctx, accountID)
+}
+
+func (h *Handler) resetSequence(ctx context.Context, ID string) {
+	if err := h.repo.Sequence.Reset(ID); err != nil {
+		logging.FromContext(ctx).Warn("failed to reset bin sequence: ", err)
+	}
+}
@@ -0,0 +1,356 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/middleware"
+	"github.com/hugocortes/hooks-api/common/problem"
+	"github.com/hugocortes/hooks-api/common/template"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/handshakes"
+	"github.com/hugocortes/hooks-api/requests/signatures"
+)
+
+const (
+	maxSecret = 255
+)
+
+// Interface exposes the bin handler over http
+type Interface struct {
+	handler bins.Handler
+}
+
+// binBody is the accepted payload when creating or updating a bin
+type binBody struct {
+	Title        string            `json:"title" binding:"required,max=255"`
+	Response     *responseBody     `json:"response"`
+	Scenario     *scenarioBody     `json:"scenario"`
+	Handshake    *handshakeBody    `json:"handshake"`
+	Verification *verificationBody `json:"verification"`
+}
+
+// verificationBody selects the provider whose signatures are verified
+type verificationBody struct {
+	Provider  string      `json:"provider" binding:"required"`
+	Secret    secretField `json:"secret"`
+	Tolerance int         `json:"tolerance_s" binding:"min=0,max=86400"`
+	Reject    bool        `json:"reject"`
+}
+
+// handshakeBody selects the provider handshake answered automatically
+type handshakeBody struct {
+	Provider string      `json:"provider" binding:"required"`
+	Secret   secretField `json:"secret"`
+}
+
+// secretField is a write only secret. An absent secret keeps the stored one
+// on update, null or an empty string clears it.
+type secretField struct {
+	Set   bool
+	Value string
+}
+
+// UnmarshalJSON ...
+func (s *secretField) UnmarshalJSON(data []byte) error {
+	s.Set = true
+	if string(data) == "null" {
+		s.Value = ""
+		return nil
+	}
+	return json.Unmarshal(data, &s.Value)
+}
+
+// responseBody configures the canned response of a bin
+type responseBody struct {
+	Status      int               `json:"status" binding:"omitempty,min=100,max=599"`
+	Headers     map[string]string `json:"headers"`
+	Body        string            `json:"body"`
+	ContentType string            `json:"content_type" binding:"max=255"`
+	Delay       int               `json:"delay_ms" binding:"min=0,max=30000"`
+	Timeout     bool              `json:"timeout"`
+	Template    bool              `json:"template"`
+}
+
+// scenarioBody scripts the responses of consecutive captured requests
+type scenarioBody struct {
+	Mode        string          `json:"mode" binding:"omitempty,oneof=cycle once random"`
+	Steps       []*responseBody `json:"steps" binding:"max=50,dive,required"`
+	FailureRate float64         `json:"failure_rate" binding:"min=0,max=1"`
+}
+
+func (r *responseBody) response() models.Response {
+	response := models.Response{
+		Status:      r.Status,
+		Headers:     models.Headers(r.Headers),
+		Body:        r.Body,
+		ContentType: r.ContentType,
+		Delay:       r.Delay,
+		Timeout:     r.Timeout,
+		Template:    r.Template,
+	}
+	response.Status = response.StatusCode()
+
+	return response
+}
+
+// validate parses the body and header templates of templated responses
+func (r *responseBody) validate() error {
+	if r == nil || !r.Template {
+		return nil
+	}
+
+	if _, err := template.Parse(r.Body); err != nil {
+		return fmt.Errorf("invalid template: %s", err)
+	}
+	for _, value := range r.Headers {
+		if _, err := template.Parse(value); err != nil {
+			return fmt.Errorf("invalid template: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the templates of the response and every scenario step, that
+// a scenario has both a mode and steps, and that the handshake and
+// verification providers are known and have the secrets they need
+func (b *binBody) validate() error {
+	if b.Handshake != nil {
+		if utf8.RuneCountInString(b.Handshake.Secret.Value) > maxSecret {
+			return errors.New("handshake secret is longer than 255 characters")
+		}
+		if _, ok := handshakes.Get(b.Handshake.Provider); !ok {
+			return fmt.Errorf("unknown handshake provider, expected one of %s", strings.Join(handshakes.Names(), ", "))
+		}
+		if b.Handshake.Secret.Value == "" && handshakes.RequiresSecret(b.Handshake.Provider) {
+			return fmt.Errorf("handshake provider %s requires a secret", b.Handshake.Provider)
+		}
+	}
+	if b.Verification != nil {
+		if _, ok := signatures.Get(b.Verification.Provider); !ok {
+			return fmt.Errorf("unknown verification provider, expected one of %s", strings.Join(signatures.Names(), ", "))
+		}
+		if b.Verification.Secret.Value == "" {
+			return errors.New("verification secret is required")
+		}
+		if utf8.RuneCountInString(b.Verification.Secret.Value) > maxSecret {
+			return errors.New("verification secret is longer than 255 characters")
+		}
+	}
+	if err := b.Response.validate(); err != nil {
+		return err
+	}
+	if b.Scenario != nil {
+		if (b.Scenario.Mode == "") != (len(b.Scenario.Steps) == 0) {
+			return errors.New("scenario mode and steps must be set together")
+		}
+		for _, step := range b.Scenario.Steps {
+			if err := step.validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// bin converts the payload, the response defaults to an empty 200 without
+// any scenario
+func (b *binBody) bin() *models.Bin {
+	bin := &models.Bin{
+		Title:    b.Title,
+		Response: models.Response{Status: http.StatusOK},
+	}
+	if b.Response != nil {
+		bin.Response = b.Response.response()
+	}
+	if b.Scenario != nil {
+		bin.Scenario = models.Scenario{
+			Mode:        b.Scenario.Mode,
+			Steps:       models.Responses{},
+			FailureRate: b.Scenario.FailureRate,
+		}
+		for _, step := range b.Scenario.Steps {
+			bin.Scenario.Steps = append(bin.Scenario.Steps, step.response())
+		}
+	}
+	if b.Handshake != nil {
+		bin.Handshake = models.Handshake{
+			Provider: b.Handshake.Provider,
+			Secret:   b.Handshake.Secret.Value,
+		}
+	}
+	if b.Verification != nil {
+		bin.Verification = models.Verification{
+			Provider:  b.Verification.Provider,
+			Secret:    b.Verification.Secret.Value,
+			Tolerance: b.Verification.Tolerance,
+			Reject:    b.Verification.Reject,
+		}
+	}
+
+	return bin
+}
+
+// keepsSecret tells whether the body leaves out a secret of the stored bin
+func (b *binBody) keepsSecret() bool {
+	return (b.Handshake != nil && !b.Handshake.Secret.Set) ||
+		(b.Verification != nil && !b.Verification.Secret.Set)
+}
+
+// keepSecrets fills the secrets the body leaves out with those of the stored
+// bin, as long as the provider is the same
+func (b *binBody) keepSecrets(stored *models.Bin) {
+	if b.Handshake != nil && !b.Handshake.Secret.Set && b.Handshake.Provider == stored.Handshake.Provider {
+		b.Handshake.Secret.Value = stored.Handshake.Secret
+	}
+	if b.Verification != nil && !b.Verification.Secret.Set && b.Verification.Provider == stored.Verification.Provider {
+		b.Verification.Secret.Value = stored.Verification.Secret
+	}
+}
+
+func init() {
+	problem.Register(bins.ErrNotFound, http.StatusNotFound)
+	problem.Register(bins.ErrConflict, http.StatusConflict)
+	problem.Register(bins.ErrValidation, http.StatusUnprocessableEntity)
+	problem.Register(bins.ErrUnavailable, http.StatusServiceUnavailable)
+}
+
+// New ...
+func New(handler bins.Handler) *Interface {
+	return &Interface{handler: handler}
+}
+
+// AddRoutes registers the bin routes. The router is expected to authenticate
+// the account of every request.
+func (i *Interface) AddRoutes(router gin.IRouter) {
+	group := router.Group("/bins")
+	group.GET("", i.getAll)
+	group.POST("", i.create)
+	group.DELETE("", i.destroy)
+
+	bin := group.Group("/:id", logging.Param("bin_id", "id"))
+	bin.GET("", i.get)
+	bin.PUT("", i.update)
+	bin.DELETE("", i.delete)
+}
+
+func (i *Interface) getAll(c *gin.Context) {
+	opts, err := gModels.ParseListOpts(c.Request.URL.Query(), models.Sorts...)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := i.handler.GetAll(c.Request.Context(), middleware.AccountID(c), opts)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if page.Bins == nil {
+		page.Bins = []*models.Bin{}
+	}
+
+	res := gin.H{"page": opts.Page, "limit": opts.GetLimit(), "bins": page.Bins}
+	if page.Next != "" {
+		res["next_cursor"] = page.Next
+	}
+	if page.Prev != "" {
+		res["prev_cursor"] = page.Prev
+	}
+	if page.Total != nil {
+		res["total"] = *page.Total
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (i *Interface) get(c *gin.Context) {
+	bin, err := i.handler.Get(c.Request.Context(), middleware.AccountID(c), c.Param("id"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bin)
+}
+
+func (i *Interface) create(c *gin.Context) {
+	body := &binBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := body.validate(); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bin := body.bin()
+	bin.AccountID = middleware.AccountID(c)
+	ID, err := i.handler.Create(c.Request.Context(), bin)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": ID})
+}
+
+func (i *Interface) update(c *gin.Context) {
+	body := &binBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.keepsSecret() {
+		stored, err := i.handler.Get(c.Request.Context(), middleware.AccountID(c), c.Param("id"))
+		if err != nil {
+			problem.Error(c, err)
+			return
+		}
+		body.keepSecrets(stored)
+	}
+	if err := body.validate(); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bin := body.bin()
+	_, err := i.handler.Update(c.Request.Context(), middleware.AccountID(c), c.Param("id"), bin)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (i *Interface) delete(c *gin.Context) {
+	_, err := i.handler.Delete(c.Request.Context(), middleware.AccountID(c), c.Param("id"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (i *Interface) destroy(c *gin.Context) {
+	affected, err := i.handler.Destroy(c.Request.Context(), middleware.AccountID(c))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": affected})
+}
@@ -0,0 +1,247 @@
+package interfaces_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/interfaces"
+	"github.com/hugocortes/hooks-api/bins/mocks"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/middleware"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/icrowley/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var router *gin.Engine
+var mockHandler *mocks.Handler
+var accountID string
+
+func testInterfaceSetup() {
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	mockHandler = new(mocks.Handler)
+	accountID = uuid.New().String()
+
+	authenticated := router.Group("", func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		middleware.SetAccountID(c, accountID)
+	})
+	interfaces.New(mockHandler).AddRoutes(authenticated)
+}
+
+func testRequest(method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMissingAccount(t *testing.T) {
+	testInterfaceSetup()
+
+	req := httptest.NewRequest("GET", "/bins", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestGetAllQueryOpts(t *testing.T) {
+	testInterfaceSetup()
+
+	opts := &gModels.QueryOpts{Page: 2, Limit: 10, Sort: models.SortCreatedAt, Order: gModels.OrderAsc}
+	mockHandler.On("GetAll", mock.Anything, accountID, opts).Return(&models.Page{}, nil)
+
+	w := testRequest("GET", "/bins?page=2&limit=10", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"page":2,"limit":10,"bins":[]}`, w.Body.String())
+	mockHandler.AssertExpectations(t)
+
+	w = testRequest("GET", "/bins?page=-1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testRequest("GET", "/bins?limit=abc", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetAllCursor(t *testing.T) {
+	testInterfaceSetup()
+
+	after := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &gModels.Cursor{Sort: models.SortTitle, Order: gModels.OrderDesc, Value: "stripe", ID: uuid.New().String()}
+	total := 12
+	opts := &gModels.QueryOpts{
+		Limit:        5,
+		Cursor:       cursor,
+		Sort:         models.SortTitle,
+		Order:        gModels.OrderDesc,
+		Title:        "str",
+		CreatedAfter: &after,
+		Total:        true,
+	}
+	page := &models.Page{Bins: []*models.Bin{{ID: cursor.ID}}, Next: "next", Prev: "prev", Total: &total}
+	mockHandler.On("GetAll", mock.Anything, accountID, opts).Return(page, nil)
+
+	w := testRequest("GET", "/bins?limit=5&title=str&created_after=2026-10-01T00:00:00Z&total=true&cursor="+cursor.Encode(), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockHandler.AssertExpectations(t)
+
+	res := map[string]interface{}{}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "next", res["next_cursor"])
+	assert.Equal(t, "prev", res["prev_cursor"])
+	assert.Equal(t, float64(12), res["total"])
+
+	for _, query := range []string{
+		"sort=account_id",
+		"order=up",
+		"cursor=garbage",
+		"page=1&cursor=" + cursor.Encode(),
+		"sort=created_at&cursor=" + cursor.Encode(),
+		"order=asc&cursor=" + cursor.Encode(),
+		"created_before=yesterday",
+		"total=maybe",
+	} {
+		w = testRequest("GET", "/bins?"+query, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	testInterfaceSetup()
+
+	title := fake.ProductName()
+	binID := uuid.New().String()
+	mockHandler.On("Create", mock.Anything, mock.MatchedBy(func(bin *models.Bin) bool {
+		return bin.Title == title && bin.AccountID == accountID
+	})).Return(binID, nil)
+
+	w := testRequest("POST", "/bins", `{"title":"`+title+`"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	res := map[string]string{}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, binID, res["id"])
+}
+
+func TestCreateValidation(t *testing.T) {
+	testInterfaceSetup()
+
+	w := testRequest("POST", "/bins", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testRequest("POST", "/bins", `{"title":"`+strings.Repeat("a", 256)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testRequest("POST", "/bins", `{"title":"crc","handshake":{"provider":"twitter"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected twitter to require a secret")
+
+	w = testRequest("POST", "/bins", `{"title":"steps","scenario":{"steps":[{"status":201}]}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected steps to require a mode")
+
+	w = testRequest("POST", "/bins", `{"title":"mode","scenario":{"mode":"cycle"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected a mode to require steps")
+
+	mockHandler.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestGetNotFound(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	mockHandler.On("Get", mock.Anything, accountID, binID).Return(nil, bins.Wrap(bins.ErrNotFound, errors.New("record not found")))
+
+	w := testRequest("GET", "/bins/"+binID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	res := map[string]interface{}{}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "Not Found", res["title"])
+	assert.Equal(t, "bin not found", res["detail"])
+	assert.Equal(t, "/bins/"+binID, res["instance"])
+}
+
+func TestRepositoryErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{bins.ErrConflict, http.StatusConflict},
+		{bins.ErrValidation, http.StatusUnprocessableEntity},
+		{bins.Wrap(bins.ErrUnavailable, errors.New("connection refused")), http.StatusServiceUnavailable},
+		{errors.New("pq: syntax error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		testInterfaceSetup()
+		mockHandler.On("Create", mock.Anything, mock.Anything).Return("", test.err)
+
+		w := testRequest("POST", "/bins", `{"title":"conflict"}`)
+		assert.Equal(t, test.status, w.Code)
+		assert.NotContains(t, w.Body.String(), "pq:", "Cause was disclosed")
+	}
+}
+
+func TestUpdateKeepsSecrets(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	stored := &models.Bin{
+		ID:           binID,
+		Handshake:    models.Handshake{Provider: "meta", Secret: "verify-token"},
+		Verification: models.Verification{Provider: "github", Secret: "signing-secret"},
+	}
+	var updated *models.Bin
+	mockHandler.On("Get", mock.Anything, accountID, binID).Return(stored, nil)
+	mockHandler.On("Update", mock.Anything, accountID, binID, mock.Anything).Return(1, nil).Run(func(args mock.Arguments) {
+		updated = args.Get(3).(*models.Bin)
+	})
+
+	w := testRequest("PUT", "/bins/"+binID, `{"title":"kept","handshake":{"provider":"meta"},"verification":{"provider":"github"}}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "verify-token", updated.Handshake.Secret, "Expected the omitted secret to be kept")
+	assert.Equal(t, "signing-secret", updated.Verification.Secret)
+
+	w = testRequest("PUT", "/bins/"+binID, `{"title":"cleared","handshake":{"provider":"meta","secret":null}}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "", updated.Handshake.Secret, "Expected null to clear the secret")
+
+	w = testRequest("PUT", "/bins/"+binID, `{"title":"other","verification":{"provider":"stripe"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected another provider not to inherit the secret")
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	missingID := uuid.New().String()
+	mockHandler.On("Update", mock.Anything, accountID, binID, mock.Anything).Return(1, nil)
+	mockHandler.On("Delete", mock.Anything, accountID, binID).Return(1, nil)
+	mockHandler.On("Delete", mock.Anything, accountID, missingID).Return(0, bins.ErrNotFound)
+
+	w := testRequest("PUT", "/bins/"+binID, `{"title":"updated"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = testRequest("DELETE", "/bins/"+binID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = testRequest("DELETE", "/bins/"+missingID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
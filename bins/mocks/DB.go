@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import hooks_apimodels "github.com/hugocortes/hooks-api/models"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/hugocortes/hooks-api/bins/models"
@@ -11,13 +12,13 @@ type DB struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: accountID, bin
-func (_m *DB) Create(accountID string, bin *models.Bin) error {
-	ret := _m.Called(accountID, bin)
+// Create provides a mock function with given fields: ctx, accountID, bin
+func (_m *DB) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	ret := _m.Called(ctx, accountID, bin)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *models.Bin) error); ok {
-		r0 = rf(accountID, bin)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.Bin) error); ok {
+		r0 = rf(ctx, accountID, bin)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -25,20 +26,20 @@ func (_m *DB) Create(accountID string, bin *models.Bin) error {
 	return r0
 }
 
-// Delete provides a mock function with given fields: accountID, ID
-func (_m *DB) Delete(accountID string, ID string) (int, error) {
-	ret := _m.Called(accountID, ID)
+// Delete provides a mock function with given fields: ctx, accountID, ID
+func (_m *DB) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	ret := _m.Called(ctx, accountID, ID)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string, string) int); ok {
-		r0 = rf(accountID, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = rf(ctx, accountID, ID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(accountID, ID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, accountID, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -46,20 +47,20 @@ func (_m *DB) Delete(accountID string, ID string) (int, error) {
 	return r0, r1
 }
 
-// Destroy provides a mock function with given fields: accountID
-func (_m *DB) Destroy(accountID string) (int, error) {
-	ret := _m.Called(accountID)
+// Destroy provides a mock function with given fields: ctx, accountID
+func (_m *DB) Destroy(ctx context.Context, accountID string) (int, error) {
+	ret := _m.Called(ctx, accountID)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(accountID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, accountID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(accountID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -67,13 +68,13 @@ func (_m *DB) Destroy(accountID string) (int, error) {
 	return r0, r1
 }
 
-// Get provides a mock function with given fields: accountID, ID
-func (_m *DB) Get(accountID string, ID string) (*models.Bin, error) {
-	ret := _m.Called(accountID, ID)
+// Get provides a mock function with given fields: ctx, accountID, ID
+func (_m *DB) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	ret := _m.Called(ctx, accountID, ID)
 
 	var r0 *models.Bin
-	if rf, ok := ret.Get(0).(func(string, string) *models.Bin); ok {
-		r0 = rf(accountID, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Bin); ok {
+		r0 = rf(ctx, accountID, ID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Bin)
@@ -81,8 +82,8 @@ func (_m *DB) Get(accountID string, ID string) (*models.Bin, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(accountID, ID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, accountID, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,22 +91,22 @@ func (_m *DB) Get(accountID string, ID string) (*models.Bin, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: accountID, opts
-func (_m *DB) GetAll(accountID string, opts *hooks_apimodels.QueryOpts) ([]*models.Bin, error) {
-	ret := _m.Called(accountID, opts)
+// GetAll provides a mock function with given fields: ctx, accountID, opts
+func (_m *DB) GetAll(ctx context.Context, accountID string, opts *hooks_apimodels.QueryOpts) (*models.Page, error) {
+	ret := _m.Called(ctx, accountID, opts)
 
-	var r0 []*models.Bin
-	if rf, ok := ret.Get(0).(func(string, *hooks_apimodels.QueryOpts) []*models.Bin); ok {
-		r0 = rf(accountID, opts)
+	var r0 *models.Page
+	if rf, ok := ret.Get(0).(func(context.Context, string, *hooks_apimodels.QueryOpts) *models.Page); ok {
+		r0 = rf(ctx, accountID, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Bin)
+			r0 = ret.Get(0).(*models.Page)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *hooks_apimodels.QueryOpts) error); ok {
-		r1 = rf(accountID, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *hooks_apimodels.QueryOpts) error); ok {
+		r1 = rf(ctx, accountID, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -113,20 +114,43 @@ func (_m *DB) GetAll(accountID string, opts *hooks_apimodels.QueryOpts) ([]*mode
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: accountID, ID, bin
-func (_m *DB) Update(accountID string, ID string, bin *models.Bin) (int, error) {
-	ret := _m.Called(accountID, ID, bin)
+// Lookup provides a mock function with given fields: ctx, ID
+func (_m *DB) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 *models.Bin
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Bin); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Bin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, accountID, ID, bin
+func (_m *DB) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	ret := _m.Called(ctx, accountID, ID, bin)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string, string, *models.Bin) int); ok {
-		r0 = rf(accountID, ID, bin)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Bin) int); ok {
+		r0 = rf(ctx, accountID, ID, bin)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, *models.Bin) error); ok {
-		r1 = rf(accountID, ID, bin)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *models.Bin) error); ok {
+		r1 = rf(ctx, accountID, ID, bin)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import hooks_apimodels "github.com/hugocortes/hooks-api/models"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/hugocortes/hooks-api/bins/models"
@@ -11,20 +12,20 @@ type Handler struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: bin
-func (_m *Handler) Create(bin *models.Bin) (string, error) {
-	ret := _m.Called(bin)
+// Create provides a mock function with given fields: ctx, bin
+func (_m *Handler) Create(ctx context.Context, bin *models.Bin) (string, error) {
+	ret := _m.Called(ctx, bin)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*models.Bin) string); ok {
-		r0 = rf(bin)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Bin) string); ok {
+		r0 = rf(ctx, bin)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Bin) error); ok {
-		r1 = rf(bin)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Bin) error); ok {
+		r1 = rf(ctx, bin)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -32,20 +33,20 @@ func (_m *Handler) Create(bin *models.Bin) (string, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: accountID, ID
-func (_m *Handler) Delete(accountID string, ID string) (int, error) {
-	ret := _m.Called(accountID, ID)
+// Delete provides a mock function with given fields: ctx, accountID, ID
+func (_m *Handler) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	ret := _m.Called(ctx, accountID, ID)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string, string) int); ok {
-		r0 = rf(accountID, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int); ok {
+		r0 = rf(ctx, accountID, ID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(accountID, ID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, accountID, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -53,20 +54,20 @@ func (_m *Handler) Delete(accountID string, ID string) (int, error) {
 	return r0, r1
 }
 
-// Destroy provides a mock function with given fields: accountID
-func (_m *Handler) Destroy(accountID string) (int, error) {
-	ret := _m.Called(accountID)
+// Destroy provides a mock function with given fields: ctx, accountID
+func (_m *Handler) Destroy(ctx context.Context, accountID string) (int, error) {
+	ret := _m.Called(ctx, accountID)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(accountID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, accountID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(accountID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -74,13 +75,13 @@ func (_m *Handler) Destroy(accountID string) (int, error) {
 	return r0, r1
 }
 
-// Get provides a mock function with given fields: accountID, ID
-func (_m *Handler) Get(accountID string, ID string) (*models.Bin, error) {
-	ret := _m.Called(accountID, ID)
+// Get provides a mock function with given fields: ctx, accountID, ID
+func (_m *Handler) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	ret := _m.Called(ctx, accountID, ID)
 
 	var r0 *models.Bin
-	if rf, ok := ret.Get(0).(func(string, string) *models.Bin); ok {
-		r0 = rf(accountID, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Bin); ok {
+		r0 = rf(ctx, accountID, ID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Bin)
@@ -88,8 +89,8 @@ func (_m *Handler) Get(accountID string, ID string) (*models.Bin, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(accountID, ID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, accountID, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -97,22 +98,22 @@ func (_m *Handler) Get(accountID string, ID string) (*models.Bin, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: accountID, opts
-func (_m *Handler) GetAll(accountID string, opts *hooks_apimodels.QueryOpts) ([]*models.Bin, error) {
-	ret := _m.Called(accountID, opts)
+// GetAll provides a mock function with given fields: ctx, accountID, opts
+func (_m *Handler) GetAll(ctx context.Context, accountID string, opts *hooks_apimodels.QueryOpts) (*models.Page, error) {
+	ret := _m.Called(ctx, accountID, opts)
 
-	var r0 []*models.Bin
-	if rf, ok := ret.Get(0).(func(string, *hooks_apimodels.QueryOpts) []*models.Bin); ok {
-		r0 = rf(accountID, opts)
+	var r0 *models.Page
+	if rf, ok := ret.Get(0).(func(context.Context, string, *hooks_apimodels.QueryOpts) *models.Page); ok {
+		r0 = rf(ctx, accountID, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Bin)
+			r0 = ret.Get(0).(*models.Page)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *hooks_apimodels.QueryOpts) error); ok {
-		r1 = rf(accountID, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *hooks_apimodels.QueryOpts) error); ok {
+		r1 = rf(ctx, accountID, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,20 +121,20 @@ func (_m *Handler) GetAll(accountID string, opts *hooks_apimodels.QueryOpts) ([]
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: accountID, ID, bin
-func (_m *Handler) Update(accountID string, ID string, bin *models.Bin) (int, error) {
-	ret := _m.Called(accountID, ID, bin)
+// Update provides a mock function with given fields: ctx, accountID, ID, bin
+func (_m *Handler) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	ret := _m.Called(ctx, accountID, ID, bin)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(string, string, *models.Bin) int); ok {
-		r0 = rf(accountID, ID, bin)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *models.Bin) int); ok {
+		r0 = rf(ctx, accountID, ID, bin)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, *models.Bin) error); ok {
-		r1 = rf(accountID, ID, bin)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *models.Bin) error); ok {
+		r1 = rf(ctx, accountID, ID, bin)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,17 +1,243 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxDelay caps the artificial delay of a response in milliseconds
+	MaxDelay = 30000
+
+	// ScenarioCycle answers with the steps in order, starting over after the last
+	ScenarioCycle = "cycle"
+	// ScenarioOnce answers with the steps in order, then with the bin response
+	ScenarioOnce = "once"
+	// ScenarioRandom answers with a random step at the scenario failure rate
+	ScenarioRandom = "random"
+
+	// SortCreatedAt sorts the bins by creation time, the default
+	SortCreatedAt = "created_at"
+	// SortUpdatedAt sorts the bins by last update time
+	SortUpdatedAt = "updated_at"
+	// SortTitle sorts the bins by title
+	SortTitle = "title"
+)
+
+// Sorts lists the fields the bins can be sorted by, the default first
+var Sorts = []string{SortCreatedAt, SortUpdatedAt, SortTitle}
 
 // Bin represents the container that holds incoming webhook payloads
 type Bin struct {
-	ID        string `gorm:"primary_key;type:char(36)"`
-	Title     string `gorm:"size:255;not null"`
-	AccountID string `gorm:"type:char(36);not null;index:idx_account_id"`
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID           string       `gorm:"primary_key;type:char(36)" json:"id"`
+	Title        string       `gorm:"size:255;not null" json:"title"`
+	AccountID    string       `gorm:"type:char(36);not null;index:idx_account_id" json:"account_id"`
+	Response     Response     `gorm:"embedded;embedded_prefix:response_" json:"response"`
+	Scenario     Scenario     `gorm:"embedded;embedded_prefix:scenario_" json:"scenario"`
+	Handshake    Handshake    `gorm:"embedded;embedded_prefix:handshake_" json:"handshake"`
+	Verification Verification `gorm:"embedded;embedded_prefix:verification_" json:"verification"`
+	CreatedAt    *time.Time   `json:"created_at"`
+	UpdatedAt    *time.Time   `json:"updated_at"`
+}
+
+// Page is a page of the bins of an account. Next and Prev are the cursors of
+// the following and preceding pages, empty when there is none. Total counts
+// the bins matching the filters, when requested.
+type Page struct {
+	Bins  []*Bin `json:"bins"`
+	Next  string `json:"next_cursor,omitempty"`
+	Prev  string `json:"prev_cursor,omitempty"`
+	Total *int   `json:"total,omitempty"`
+}
+
+// Response is the canned answer the bin gives to every captured request
+type Response struct {
+	Status      int     `gorm:"not null;default:200" json:"status"`
+	Headers     Headers `gorm:"type:text" json:"headers"`
+	Body        string  `gorm:"type:text" json:"body"`
+	ContentType string  `gorm:"size:255" json:"content_type"`
+	Delay       int     `gorm:"not null;default:0" json:"delay_ms"`
+	Timeout     bool    `gorm:"not null;default:false" json:"timeout"`
+	Template    bool    `gorm:"not null;default:false" json:"template"`
+}
+
+// Scenario scripts the responses of consecutive captured requests, falling
+// back to the bin response
+type Scenario struct {
+	Mode        string    `gorm:"size:16" json:"mode"`
+	Steps       Responses `gorm:"type:text" json:"steps"`
+	FailureRate float64   `gorm:"not null;default:0" json:"failure_rate"`
+}
+
+// Handshake selects the provider whose verification requests are answered
+// automatically, the secret is required by some providers to sign the answer.
+// The secret is write only, the bin only tells whether it has one.
+type Handshake struct {
+	Provider string `gorm:"size:32" json:"provider"`
+	Secret   string `gorm:"size:255" json:"-"`
 }
 
+// Verification checks the provider signature of every captured request.
+// Tolerance bounds the age of signed timestamps in seconds, Reject answers 401
+// to requests that are not verified. The secret is write only, as the
+// handshake one.
+type Verification struct {
+	Provider  string `gorm:"size:32" json:"provider"`
+	Secret    string `gorm:"size:255" json:"-"`
+	Tolerance int    `gorm:"not null;default:0" json:"tolerance_s"`
+	Reject    bool   `gorm:"not null;default:false" json:"reject"`
+}
+
+// Responses stores the scenario steps as json
+type Responses []Response
+
+// Headers stores the response headers as json
+type Headers map[string]string
+
 // Initialized validates if Bin is intialized
 func (m *Bin) Initialized() bool {
 	return m.ID != "" && m.CreatedAt != nil && m.UpdatedAt != nil
 }
+
+// Sequenced reports whether picking a response needs the request position
+func (m *Bin) Sequenced() bool {
+	mode := m.Scenario.Mode
+	return len(m.Scenario.Steps) > 0 && (mode == ScenarioCycle || mode == ScenarioOnce)
+}
+
+// Pick returns the response for the request at the 1-based position, roll is
+// a random number in [0, 1) used by random scenarios
+func (m *Bin) Pick(position int64, roll float64) *Response {
+	steps := m.Scenario.Steps
+	if len(steps) == 0 {
+		return &m.Response
+	}
+
+	switch m.Scenario.Mode {
+	case ScenarioCycle:
+		if position > 0 {
+			return &steps[(position-1)%int64(len(steps))]
+		}
+	case ScenarioOnce:
+		if position > 0 && position <= int64(len(steps)) {
+			return &steps[position-1]
+		}
+	case ScenarioRandom:
+		rate := m.Scenario.FailureRate
+		if roll < rate {
+			// spread the failing rolls evenly over the steps
+			return &steps[int(roll/rate*float64(len(steps)))%len(steps)]
+		}
+	}
+
+	return &m.Response
+}
+
+// MarshalJSON replaces the secret by whether it is set
+func (h Handshake) MarshalJSON() ([]byte, error) {
+	type handshake Handshake
+	return json.Marshal(struct {
+		handshake
+		HasSecret bool `json:"has_secret"`
+	}{handshake(h), h.Secret != ""})
+}
+
+// MarshalJSON replaces the secret by whether it is set
+func (v Verification) MarshalJSON() ([]byte, error) {
+	type verification Verification
+	return json.Marshal(struct {
+		verification
+		HasSecret bool `json:"has_secret"`
+	}{verification(v), v.Secret != ""})
+}
+
+// StatusCode returns the configured status or 200 when none is set
+func (r *Response) StatusCode() int {
+	if r.Status == 0 {
+		return http.StatusOK
+	}
+	return r.Status
+}
+
+// Value implements driver.Valuer
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+
+	marshalled, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshalled), nil
+}
+
+// MarshalJSON renders no headers as an empty object, as they are stored
+func (h Headers) MarshalJSON() ([]byte, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(h))
+}
+
+// Scan implements sql.Scanner
+func (h *Headers) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*h = Headers{}
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return errors.New("unsupported headers value")
+	}
+
+	return json.Unmarshal(raw, h)
+}
+
+// Value implements driver.Valuer
+func (r Responses) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+
+	marshalled, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshalled), nil
+}
+
+// MarshalJSON renders no steps as an empty array, as they are stored
+func (r Responses) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]Response(r))
+}
+
+// Scan implements sql.Scanner
+func (r *Responses) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*r = Responses{}
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return errors.New("unsupported responses value")
+	}
+
+	return json.Unmarshal(raw, r)
+}
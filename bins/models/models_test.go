@@ -0,0 +1,74 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testScenarioBin(mode string, rate float64) *models.Bin {
+	return &models.Bin{
+		Response: models.Response{Status: 200},
+		Scenario: models.Scenario{
+			Mode:        mode,
+			Steps:       models.Responses{{Status: 500}, {Status: 503}},
+			FailureRate: rate,
+		},
+	}
+}
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		name     string
+		bin      *models.Bin
+		position int64
+		roll     float64
+		status   int
+	}{
+		{"no scenario", &models.Bin{Response: models.Response{Status: 202}}, 1, 0, 202},
+		{"no steps", &models.Bin{Response: models.Response{Status: 202}, Scenario: models.Scenario{Mode: models.ScenarioCycle}}, 1, 0, 202},
+		{"cycle first", testScenarioBin(models.ScenarioCycle, 0), 1, 0, 500},
+		{"cycle second", testScenarioBin(models.ScenarioCycle, 0), 2, 0, 503},
+		{"cycle wraps", testScenarioBin(models.ScenarioCycle, 0), 3, 0, 500},
+		{"cycle unknown position", testScenarioBin(models.ScenarioCycle, 0), 0, 0, 200},
+		{"once first", testScenarioBin(models.ScenarioOnce, 0), 1, 0, 500},
+		{"once last", testScenarioBin(models.ScenarioOnce, 0), 2, 0, 503},
+		{"once then default", testScenarioBin(models.ScenarioOnce, 0), 3, 0, 200},
+		{"random fails", testScenarioBin(models.ScenarioRandom, 0.5), 0, 0.1, 500},
+		{"random fails last step", testScenarioBin(models.ScenarioRandom, 0.5), 0, 0.4, 503},
+		{"random succeeds", testScenarioBin(models.ScenarioRandom, 0.5), 0, 0.5, 200},
+		{"random never fails", testScenarioBin(models.ScenarioRandom, 0), 0, 0, 200},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.status, test.bin.Pick(test.position, test.roll).Status, test.name)
+	}
+}
+
+func TestSequenced(t *testing.T) {
+	assert.True(t, testScenarioBin(models.ScenarioCycle, 0).Sequenced())
+	assert.True(t, testScenarioBin(models.ScenarioOnce, 0).Sequenced())
+	assert.False(t, testScenarioBin(models.ScenarioRandom, 0.5).Sequenced())
+	assert.False(t, (&models.Bin{Scenario: models.Scenario{Mode: models.ScenarioCycle}}).Sequenced())
+}
+
+func TestSecretsHidden(t *testing.T) {
+	bin := &models.Bin{
+		Handshake:    models.Handshake{Provider: "zoom", Secret: "signing"},
+		Verification: models.Verification{Provider: "github", Secret: "verifying"},
+	}
+
+	marshalled, err := json.Marshal(bin)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(marshalled), "signing")
+	assert.NotContains(t, string(marshalled), "verifying")
+
+	res := map[string]map[string]interface{}{}
+	json.Unmarshal(marshalled, &res)
+	assert.Equal(t, map[string]interface{}{"provider": "zoom", "has_secret": true}, res["handshake"])
+	assert.Equal(t, true, res["verification"]["has_secret"])
+	assert.Contains(t, string(marshalled), `"headers":{}`, "Expected no headers as an empty object")
+	assert.Contains(t, string(marshalled), `"steps":[]`, "Expected no steps as an empty array")
+}
@@ -1,21 +1,31 @@
 package bins
 
 import (
+	"context"
+
 	"github.com/hugocortes/hooks-api/bins/models"
 	gModels "github.com/hugocortes/hooks-api/models"
 )
 
 // Repository ...
 type Repository struct {
-	DB DB
+	DB       DB
+	Sequence Sequence
 }
 
-// DB ...
+// DB stores the bins. Every method gives up once ctx is done.
 type DB interface {
-	GetAll(accountID string, opts *gModels.QueryOpts) ([]*models.Bin, error)
-	Get(accountID string, ID string) (*models.Bin, error)
-	Create(accountID string, bin *models.Bin) error
-	Update(accountID string, ID string, bin *models.Bin) (int, error)
-	Delete(accountID string, ID string) (int, error)
-	Destroy(accountID string) (int, error)
+	GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error)
+	Get(ctx context.Context, accountID string, ID string) (*models.Bin, error)
+	Lookup(ctx context.Context, ID string) (*models.Bin, error)
+	Create(ctx context.Context, accountID string, bin *models.Bin) error
+	Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error)
+	Delete(ctx context.Context, accountID string, ID string) (int, error)
+	Destroy(ctx context.Context, accountID string) (int, error)
+}
+
+// Sequence counts the requests answered by a bin scenario
+type Sequence interface {
+	Next(binID string) (int64, error)
+	Reset(binID string) error
 }
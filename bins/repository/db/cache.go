@@ -1,78 +1,305 @@
 package db
 
 import (
-	"encoding/json"
-	"log"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/google/uuid"
 	"github.com/hugocortes/hooks-api/bins"
 	"github.com/hugocortes/hooks-api/bins/models"
 	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/metrics"
 	gModels "github.com/hugocortes/hooks-api/models"
+	"golang.org/x/sync/singleflight"
 )
 
-// CacheRepo ..
+const (
+	// versionExpiration outlives every entry cached under a version, the
+	// configuration refuses longer cache expirations
+	versionExpiration = 24 * time.Hour
+	// lockExpiration frees the lock of an instance that died while filling
+	lockExpiration = 5 * time.Second
+	lockWait       = time.Second
+	lockPoll       = 25 * time.Millisecond
+	// tombstone is cached for missing bins. It is not a valid gob stream, so
+	// it cannot be mistaken for an entry.
+	tombstone = "tombstone"
+)
+
+// CacheRepo caches the bins of an account under the account version. Every
+// write bumps the version, which hides all the entries of the account at once.
+// Entries expire after Expiration, misses after TombstoneExpiration.
 type CacheRepo struct {
-	DB    bins.DB
-	Cache *redis.Client
+	DB                  bins.DB
+	Cache               cache.Cache
+	Expiration          time.Duration
+	TombstoneExpiration time.Duration
+	Metrics             *metrics.Metrics
+
+	group singleflight.Group
 }
 
 // GetAll ...
-func (r *CacheRepo) GetAll(accountID string, opts *gModels.QueryOpts) ([]*models.Bin, error) {
-	return r.DB.GetAll(accountID, opts)
+func (r *CacheRepo) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	version, err := r.version(ctx, accountID)
+	if err != nil {
+		return r.DB.GetAll(ctx, accountID, opts)
+	}
+
+	page := &models.Page{}
+	err = r.through(ctx, "GetAll", cache.GenKey("GetAll", accountID, version, opts.Key()), page, func() (interface{}, error) {
+		return r.DB.GetAll(ctx, accountID, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
 }
 
 // Get ...
-func (r *CacheRepo) Get(accountID string, ID string) (*models.Bin, error) {
-	cacheKey := cache.GenKey("Get", accountID, ID)
-	cachedValue := r.Cache.Get(cacheKey)
+func (r *CacheRepo) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	version, err := r.version(ctx, accountID)
+	if err != nil {
+		return r.DB.Get(ctx, accountID, ID)
+	}
+
 	bin := &models.Bin{}
+	err = r.through(ctx, "Get", cache.GenKey("Get", accountID, version, ID), bin, func() (interface{}, error) {
+		return r.DB.Get(ctx, accountID, ID)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	var err error
-	if cachedValue.Val() != "" {
-		err := json.Unmarshal([]byte(cachedValue.Val()), bin)
-		if err != nil {
-			log.Printf("unmarshalling caching error: %s", err.Error())
-			return nil, err
-		}
-	} else {
-		bin, err = r.DB.Get(accountID, ID)
-		if err != nil {
-			return nil, err
-		}
+	return bin, nil
+}
 
-		marshalled, err := json.Marshal(bin)
+// Lookup caches the account of the bin, which never changes, and reads the
+// bin itself through Get so it follows the account version. Each read counts
+// a cache lookup under its own method.
+func (r *CacheRepo) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	var accountID string
+	err := r.through(ctx, "Lookup", cache.GenKey("Lookup", ID), &accountID, func() (interface{}, error) {
+		bin, err := r.DB.Lookup(ctx, ID)
 		if err != nil {
-			log.Printf("marshalling caching error: %s", err.Error())
 			return nil, err
 		}
-
-		r.Cache.Set(cacheKey, marshalled, cache.Expiration())
+		return bin.AccountID, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return bin, err
+	return r.Get(ctx, accountID, ID)
 }
 
 // Create ...
-func (r *CacheRepo) Create(accountID string, bin *models.Bin) error {
-	return r.DB.Create(accountID, bin)
+func (r *CacheRepo) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	err := r.DB.Create(ctx, accountID, bin)
+	if err == nil {
+		r.bump(ctx, accountID)
+	}
+
+	return err
 }
 
 // Update ...
-func (r *CacheRepo) Update(accountID string, ID string, bin *models.Bin) (int, error) {
-	r.Cache.Del(cache.GenKey("Get", accountID, ID))
+func (r *CacheRepo) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	affected, err := r.DB.Update(ctx, accountID, ID, bin)
+	if err == nil {
+		r.bump(ctx, accountID)
+	}
 
-	return r.DB.Update(accountID, ID, bin)
+	return affected, err
 }
 
 // Delete ...
-func (r *CacheRepo) Delete(accountID string, ID string) (int, error) {
-	r.Cache.Del(cache.GenKey("Get", accountID, ID))
+func (r *CacheRepo) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	affected, err := r.DB.Delete(ctx, accountID, ID)
+	if err == nil {
+		r.bump(ctx, accountID)
+	}
 
-	return r.DB.Delete(accountID, ID)
+	return affected, err
 }
 
 // Destroy ...
-func (r *CacheRepo) Destroy(accountID string) (int, error) {
-	return r.DB.Destroy(accountID)
+func (r *CacheRepo) Destroy(ctx context.Context, accountID string) (int, error) {
+	affected, err := r.DB.Destroy(ctx, accountID)
+	if err == nil {
+		r.bump(ctx, accountID)
+	}
+
+	return affected, err
+}
+
+// through decodes the entry cached under cacheKey into value or fills it with
+// load. Missing bins are cached as tombstones. Concurrent misses share one
+// fill per instance and instances take turns through a lock, so a hot miss
+// reaches Postgres once. The result is counted under the repository method.
+func (r *CacheRepo) through(ctx context.Context, method string, cacheKey string, value interface{}, load func() (interface{}, error)) error {
+	if cached, ok := r.fetch(ctx, cacheKey); ok {
+		err := decode(cached, value)
+		switch err {
+		case nil:
+			r.Metrics.Cache(method, metrics.CacheHit)
+			return nil
+		case bins.ErrNotFound:
+			r.Metrics.Cache(method, metrics.CacheTombstone)
+			return err
+		}
+		logging.FromContext(ctx).Warn("discarding unreadable cached bin ", cacheKey)
+		r.invalidate(ctx, cacheKey)
+	}
+	r.Metrics.Cache(method, metrics.CacheMiss)
+
+	filled := r.group.DoChan(cacheKey, func() (interface{}, error) {
+		return r.fill(ctx, cacheKey, load)
+	})
+
+	select {
+	case result := <-filled:
+		if result.Err != nil && result.Shared && ctx.Err() == nil && errors.Is(result.Err, context.Canceled) {
+			// the caller that filled gave up, this one did not
+			return r.through(ctx, method, cacheKey, value, load)
+		}
+		if result.Err != nil {
+			return result.Err
+		}
+		return decode(result.Val.([]byte), value)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fill loads the entry and caches it, or a tombstone when the bin is missing.
+// While another instance holds the lock, its entry is awaited instead.
+func (r *CacheRepo) fill(ctx context.Context, cacheKey string, load func() (interface{}, error)) ([]byte, error) {
+	lockKey := cacheKey + ":Lock"
+	token := uuid.New().String()
+
+	locked, err := r.Cache.SetNX(ctx, lockKey, []byte(token), lockExpiration)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Warn("bin cache unavailable: ", err)
+	case locked:
+		defer r.unlock(ctx, lockKey, token)
+	default:
+		if cached, ok := r.await(ctx, cacheKey); ok {
+			return cached, nil
+		}
+	}
+
+	loaded, err := load()
+	if errors.Is(err, bins.ErrNotFound) {
+		r.store(ctx, cacheKey, []byte(tombstone), r.TombstoneExpiration)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := &bytes.Buffer{}
+	if err := gob.NewEncoder(encoded).Encode(loaded); err != nil {
+		return nil, err
+	}
+	r.store(ctx, cacheKey, encoded.Bytes(), r.Expiration)
+
+	return encoded.Bytes(), nil
+}
+
+// await polls for the entry of the instance holding the lock
+func (r *CacheRepo) await(ctx context.Context, cacheKey string) ([]byte, bool) {
+	deadline := time.NewTimer(lockWait)
+	defer deadline.Stop()
+	poll := time.NewTicker(lockPoll)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-deadline.C:
+			return nil, false
+		case <-poll.C:
+			if cached, ok := r.fetch(ctx, cacheKey); ok {
+				return cached, true
+			}
+		}
+	}
+}
+
+// unlock releases the lock unless it expired and was taken by another
+// instance. It runs regardless of the caller giving up, so that the lock does
+// not outlive the fill.
+func (r *CacheRepo) unlock(ctx context.Context, lockKey string, token string) {
+	if err := r.Cache.CompareAndDelete(context.WithoutCancel(ctx), lockKey, []byte(token)); err != nil {
+		logging.FromContext(ctx).Warn("bin cache unlock failed: ", err)
+	}
+}
+
+// version returns the current cache version of the account. Nothing should
+// be cached when it cannot be read.
+func (r *CacheRepo) version(ctx context.Context, accountID string) (string, error) {
+	version, err := r.Cache.Get(ctx, cache.GenKey("Version", accountID))
+	if err == cache.ErrMiss {
+		return "0", nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("bin cache unavailable: ", err)
+		return "", err
+	}
+
+	return string(version), nil
+}
+
+// bump moves the account to a new cache version. It runs regardless of the
+// caller giving up, as the previous version would serve stale entries.
+func (r *CacheRepo) bump(ctx context.Context, accountID string) {
+	_, err := r.Cache.Incr(context.WithoutCancel(ctx), cache.GenKey("Version", accountID), versionExpiration)
+	if err != nil {
+		logging.FromContext(ctx).Warn("bin cache version bump failed: ", err)
+	}
+}
+
+// fetch returns the entry cached under cacheKey
+func (r *CacheRepo) fetch(ctx context.Context, cacheKey string) ([]byte, bool) {
+	cached, err := r.Cache.Get(ctx, cacheKey)
+	if err != nil {
+		if err != cache.ErrMiss {
+			logging.FromContext(ctx).Warn("bin cache unavailable: ", err)
+		}
+		return nil, false
+	}
+
+	return cached, true
+}
+
+// store caches the entry under cacheKey
+func (r *CacheRepo) store(ctx context.Context, cacheKey string, entry []byte, expiration time.Duration) {
+	if err := r.Cache.Set(ctx, cacheKey, entry, expiration); err != nil {
+		logging.FromContext(ctx).Warn("bin cache unavailable: ", err)
+	}
+}
+
+// invalidate deletes the keys regardless of the caller giving up, as a stale
+// entry would outlive the request
+func (r *CacheRepo) invalidate(ctx context.Context, keys ...string) {
+	if err := r.Cache.Del(context.WithoutCancel(ctx), keys...); err != nil {
+		logging.FromContext(ctx).Warn("bin cache invalidation failed: ", err)
+	}
+}
+
+// decode reads a cached entry into value. A tombstone is a missing bin.
+// Entries are gob encoded, as json leaves the secrets of the bins out.
+func decode(cached []byte, value interface{}) error {
+	if string(cached) == tombstone {
+		return bins.ErrNotFound
+	}
+	return gob.NewDecoder(bytes.NewReader(cached)).Decode(value)
 }
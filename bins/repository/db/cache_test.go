@@ -1,16 +1,21 @@
 package db_test
 
 import (
-	"os"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/go-redis/redis"
 	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
 	"github.com/hugocortes/hooks-api/bins/mocks"
 	"github.com/hugocortes/hooks-api/bins/models"
 	binsDB "github.com/hugocortes/hooks-api/bins/repository/db"
 	"github.com/hugocortes/hooks-api/common/cache"
-	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/metrics"
+	gModels "github.com/hugocortes/hooks-api/models"
 	"github.com/icrowley/fake"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,22 +25,22 @@ const (
 	testPrefix = "hooks-api-test"
 )
 
-var testRedisClient *redis.Client
+var testMemory *cache.Memory
 var testCache *binsDB.CacheRepo
 var mockDB *mocks.DB
 var mockBins []models.Bin
 var accountID string
 
 func testCacheSetup() {
-	deps.LoadEnv("../../../.env")
-	testRedisClient = deps.Redis()
-
 	mockDB = new(mocks.DB)
 
-	os.Setenv("REDIS_KEY", testPrefix)
+	cache.SetPrefix(testPrefix)
+	testMemory = cache.NewMemory(0)
 	testCache = &binsDB.CacheRepo{
-		Cache: deps.Redis(),
-		DB:    mockDB,
+		Cache:               testMemory,
+		DB:                  mockDB,
+		Expiration:          time.Minute,
+		TombstoneExpiration: time.Minute,
 	}
 
 	accountID = uuid.New().String()
@@ -54,7 +59,7 @@ func testCacheSetup() {
 }
 
 func testCacheTearDown() {
-	testCache.Cache.FlushAll()
+	cache.SetPrefix("")
 }
 
 func TestCachedGet(t *testing.T) {
@@ -64,25 +69,42 @@ func TestCachedGet(t *testing.T) {
 	bin := mockBins[0]
 
 	var rawQueryCount = 0
-	mockDB.On("Get", accountID, bin.ID).Return(&bin, nil).Run(func(args mock.Arguments) {
+	mockDB.On("Get", mock.Anything, accountID, bin.ID).Return(&bin, nil).Run(func(args mock.Arguments) {
 		rawQueryCount++
 	})
 
 	// raw query
-	testCache.Get(bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 	// cached
-	testCache.Get(bin.AccountID, bin.ID)
-	testCache.Get(bin.AccountID, bin.ID)
-	testCache.Get(bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 
-	testRedisClient.Del(cache.GenKey("Get", accountID, bin.ID))
-	testCache.Get(bin.AccountID, bin.ID)
-	testCache.Get(bin.AccountID, bin.ID)
-	testCache.Get(bin.AccountID, bin.ID)
+	testMemory.Del(ctx, cache.GenKey("Get", accountID, "0", bin.ID))
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 
 	assert.True(t, rawQueryCount == 2, "Query was called more than once")
 }
 
+func TestCachedSecrets(t *testing.T) {
+	testCacheSetup()
+	defer testCacheTearDown()
+
+	bin := mockBins[0]
+	bin.Handshake = models.Handshake{Provider: "zoom", Secret: "signing"}
+	bin.Verification = models.Verification{Provider: "github", Secret: "verifying"}
+	mockDB.On("Get", mock.Anything, accountID, bin.ID).Return(&bin, nil).Once()
+
+	// raw query, then cached
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	cached, err := testCache.Get(ctx, bin.AccountID, bin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "signing", cached.Handshake.Secret, "Expected the cached bin to keep its secrets")
+	assert.Equal(t, "verifying", cached.Verification.Secret, "Expected the cached bin to keep its secrets")
+}
+
 func TestCacheInvalidation(t *testing.T) {
 	testCacheSetup()
 	defer testCacheTearDown()
@@ -91,27 +113,163 @@ func TestCacheInvalidation(t *testing.T) {
 
 	var rawQueryCount = 0
 	var updated = false
-	mockDB.On("Get", accountID, bin.ID).Return(&bin, nil).Run(func(args mock.Arguments) {
+	mockDB.On("Get", mock.Anything, accountID, bin.ID).Return(&bin, nil).Run(func(args mock.Arguments) {
 		rawQueryCount++
 	})
-	mockDB.On("Update", accountID, bin.ID, &bin).Return(1, nil).Run(func(args mock.Arguments) {
+	mockDB.On("Update", mock.Anything, accountID, bin.ID, &bin).Return(1, nil).Run(func(args mock.Arguments) {
 		updated = true
 	})
 
 	// raw query, rawQueryCount incremented
-	testCache.Get(bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 
 	// cached response
-	testCache.Get(bin.AccountID, bin.ID)
-	testCache.Get(bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 
 	// cache invalidate
 	bin.Title = fake.ProductName()
-	testCache.Update(bin.AccountID, bin.ID, &bin)
+	testCache.Update(ctx, bin.AccountID, bin.ID, &bin)
 
 	// raw query, rawQueryCount incremented
-	testCache.Get(bin.AccountID, bin.ID)
+	testCache.Get(ctx, bin.AccountID, bin.ID)
 
 	assert.True(t, updated, "Updated was not mocked")
 	assert.True(t, rawQueryCount == 2, "Query was called more than once")
 }
+
+func TestCachedGetAll(t *testing.T) {
+	testCacheSetup()
+	defer testCacheTearDown()
+
+	bins := []*models.Bin{&mockBins[0], &mockBins[1]}
+	opts := &gModels.QueryOpts{Page: 0, Limit: 10}
+	page := &models.Page{Bins: bins}
+
+	var rawQueryCount = 0
+	mockDB.On("GetAll", mock.Anything, accountID, opts).Return(page, nil).Run(func(args mock.Arguments) {
+		rawQueryCount++
+	})
+	mockDB.On("Get", mock.Anything, accountID, bins[0].ID).Return(bins[0], nil)
+	mockDB.On("Destroy", mock.Anything, accountID).Return(2, nil)
+
+	// raw query, then cached
+	cached, err := testCache.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cached.Bins))
+	testCache.GetAll(ctx, accountID, opts)
+	assert.Equal(t, 1, rawQueryCount, "Query was called more than once")
+
+	// other pages, sorts and filters are cached separately
+	for _, other := range []*gModels.QueryOpts{
+		{Page: 1, Limit: 10},
+		{Limit: 10, Sort: models.SortTitle},
+		{Limit: 10, Title: "stripe"},
+		{Limit: 10, Cursor: &gModels.Cursor{Sort: models.SortCreatedAt, Value: "2026-10-18T00:00:00Z", ID: bins[1].ID}},
+	} {
+		mockDB.On("GetAll", mock.Anything, accountID, other).Return(&models.Page{}, nil)
+		testCache.GetAll(ctx, accountID, other)
+		mockDB.AssertCalled(t, "GetAll", mock.Anything, accountID, other)
+	}
+
+	// destroying the account hides every cached entry of the account
+	testCache.Get(ctx, accountID, bins[0].ID)
+	testCache.Destroy(ctx, accountID)
+
+	mockDB.ExpectedCalls = nil
+	mockDB.On("GetAll", mock.Anything, accountID, opts).Return(&models.Page{}, nil)
+	mockDB.On("Get", mock.Anything, accountID, bins[0].ID).Return(nil, errors.New("bin not found"))
+
+	cached, err = testCache.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(cached.Bins), "Listing was served from the previous version")
+
+	bin, err := testCache.Get(ctx, accountID, bins[0].ID)
+	assert.NotNil(t, err)
+	assert.Nil(t, bin, "Bin was served from the previous version")
+}
+
+func TestCacheTombstone(t *testing.T) {
+	testCacheSetup()
+	defer testCacheTearDown()
+	testCache.TombstoneExpiration = 50 * time.Millisecond
+
+	binID := uuid.New().String()
+
+	var rawQueryCount = 0
+	mockDB.On("Get", mock.Anything, accountID, binID).Return(nil, bins.ErrNotFound).Run(func(args mock.Arguments) {
+		rawQueryCount++
+	})
+
+	for i := 0; i < 3; i++ {
+		bin, err := testCache.Get(ctx, accountID, binID)
+		assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+		assert.Nil(t, bin, "Missing bin was served as a zero value")
+	}
+	assert.Equal(t, 1, rawQueryCount, "Miss was not cached")
+
+	time.Sleep(60 * time.Millisecond)
+	testCache.Get(ctx, accountID, binID)
+	assert.Equal(t, 2, rawQueryCount, "Tombstone did not use its own expiration")
+}
+
+func TestCacheMetrics(t *testing.T) {
+	testCacheSetup()
+	defer testCacheTearDown()
+	testCache.Metrics = metrics.New(config.Default().Metrics)
+
+	bin := mockBins[0]
+	missing := uuid.New().String()
+	mockDB.On("Get", mock.Anything, accountID, bin.ID).Return(&bin, nil)
+	mockDB.On("Get", mock.Anything, accountID, missing).Return(nil, bins.ErrNotFound)
+
+	for i := 0; i < 3; i++ {
+		testCache.Get(ctx, accountID, bin.ID)
+	}
+	testCache.Get(ctx, accountID, missing)
+	testCache.Get(ctx, accountID, missing)
+
+	mockDB.On("Lookup", mock.Anything, bin.ID).Return(&bin, nil)
+	testCache.Lookup(ctx, bin.ID)
+
+	scraped := testScrape(testCache.Metrics)
+	assert.Contains(t, scraped, `hooks_bins_cache_lookups_total{method="Get",result="hit"} 3`)
+	assert.Contains(t, scraped, `hooks_bins_cache_lookups_total{method="Get",result="miss"} 2`)
+	assert.Contains(t, scraped, `hooks_bins_cache_lookups_total{method="Get",result="tombstone"} 1`)
+	assert.Contains(t, scraped, `hooks_bins_cache_lookups_total{method="Lookup",result="miss"} 1`)
+}
+
+func TestCacheCoalescing(t *testing.T) {
+	testCacheSetup()
+	defer testCacheTearDown()
+
+	bin := mockBins[0]
+
+	var rawQueryCount int32
+	mockDB.On("Get", mock.Anything, accountID, bin.ID).Return(&bin, nil).Run(func(args mock.Arguments) {
+		atomic.AddInt32(&rawQueryCount, 1)
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	// a second instance shares Redis but not the in-process coalescing
+	other := &binsDB.CacheRepo{Cache: testMemory, DB: mockDB, Expiration: time.Minute}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		repo := testCache
+		if i%2 == 1 {
+			repo = other
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached, err := repo.Get(ctx, accountID, bin.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, bin.ID, cached.ID)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&rawQueryCount), "Hot miss fanned out")
+}
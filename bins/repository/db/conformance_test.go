@@ -0,0 +1,463 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/icrowley/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+// conformance lists the behaviors shared by every bins.DB implementation.
+// Each test runs on its own account, destroyed afterwards.
+var conformance = map[string]func(t *testing.T, repo bins.DB, accountID string){
+	"GetNoBins":        testGetNoBins,
+	"CreateBin":        testCreateBinSetsID,
+	"UpdateBin":        testUpdateBin,
+	"GetBin":           testGetBin,
+	"BinResponse":      testBinResponse,
+	"DeleteBin":        testDeleteBin,
+	"DestroyBins":      testDestroyBins,
+	"GetAllBins":       testGetAllBins,
+	"GetAllOrder":      testGetAllOrder,
+	"GetAllCursor":     testGetAllCursor,
+	"GetAllSort":       testGetAllSort,
+	"GetAllFilters":    testGetAllFilters,
+	"GetAllErrors":     testGetAllErrors,
+	"AccountIsolation": testAccountIsolation,
+	"GetErrors":        testGetErrors,
+	"Canceled":         testCanceled,
+	"Secrets":          testSecrets,
+}
+
+func testConformance(t *testing.T, repo bins.DB) {
+	for name, test := range conformance {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			accountID := uuid.New().String()
+			defer repo.Destroy(ctx, accountID)
+
+			test(t, repo, accountID)
+		})
+	}
+}
+
+func testGetNoBins(t *testing.T, repo bins.DB, accountID string) {
+	opts := &gModels.QueryOpts{
+		Page:  0,
+		Limit: 10,
+	}
+
+	found, err := repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(found.Bins))
+	assert.Equal(t, "", found.Next)
+	assert.Equal(t, "", found.Prev)
+}
+
+func testCreateBinSetsID(t *testing.T, repo bins.DB, accountID string) {
+	bin := &models.Bin{
+		Title: fake.ProductName(),
+	}
+
+	err := repo.Create(ctx, accountID, bin)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", bin.ID, "BinID was not set")
+	assert.Equal(t, accountID, bin.AccountID)
+}
+
+func testUpdateBin(t *testing.T, repo bins.DB, accountID string) {
+	createdBin := testCreateBin(repo, accountID)
+	updatedTs := createdBin.UpdatedAt
+
+	time.Sleep(10 * time.Millisecond)
+
+	createdBin.Title = "Updated Name"
+	affected, err := repo.Update(ctx, accountID, createdBin.ID, createdBin)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, affected)
+
+	bin, err := repo.Get(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, createdBin.Title, bin.Title)
+	assert.Equal(t, createdBin.CreatedAt.Unix(), bin.CreatedAt.Unix())
+	assert.NotEqual(t, updatedTs.UTC(), bin.UpdatedAt.UTC())
+}
+
+func testGetBin(t *testing.T, repo bins.DB, accountID string) {
+	createdBin := testCreateBin(repo, accountID)
+
+	bin, err := repo.Get(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, createdBin.ID, bin.ID)
+	assert.Equal(t, accountID, bin.AccountID)
+	assert.Equal(t, createdBin.Title, bin.Title)
+
+	bin, err = repo.Lookup(ctx, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, createdBin.ID, bin.ID)
+	assert.Equal(t, accountID, bin.AccountID)
+}
+
+func testBinResponse(t *testing.T, repo bins.DB, accountID string) {
+	createdBin := testCreateBin(repo, accountID)
+
+	bin, err := repo.Get(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, bin.Response.Status, "Expected default status")
+
+	createdBin.Response = models.Response{
+		Status:      410,
+		Headers:     models.Headers{"X-Reason": "unsubscribed"},
+		Body:        "gone",
+		ContentType: "text/plain",
+		Delay:       100,
+	}
+	repo.Update(ctx, accountID, createdBin.ID, createdBin)
+
+	bin, err = repo.Get(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, createdBin.Response, bin.Response)
+
+	createdBin.Response = models.Response{Status: 200}
+	repo.Update(ctx, accountID, createdBin.ID, createdBin)
+
+	bin, err = repo.Get(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "", bin.Response.Body, "Expected body to be cleared")
+	assert.Equal(t, 0, bin.Response.Delay, "Expected delay to be cleared")
+}
+
+func testDeleteBin(t *testing.T, repo bins.DB, accountID string) {
+	createdBin := testCreateBin(repo, accountID)
+
+	affected, err := repo.Delete(ctx, accountID, createdBin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, affected, "No entry was deleted")
+
+	bin, err := repo.Get(ctx, accountID, createdBin.ID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+	assert.Nil(t, bin)
+}
+
+func testDestroyBins(t *testing.T, repo bins.DB, accountID string) {
+	var created []*models.Bin
+	for i := 0; i < 10; i++ {
+		created = append(created, testCreateBin(repo, accountID))
+	}
+
+	affected, err := repo.Destroy(ctx, accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, affected)
+
+	for i := 0; i < 10; i++ {
+		bin, err := repo.Get(ctx, accountID, created[i].ID)
+		assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+		assert.Nil(t, bin, "Expected nil bin")
+	}
+}
+
+func testGetAllBins(t *testing.T, repo bins.DB, accountID string) {
+	for i := 0; i < 25; i++ {
+		testCreateBin(repo, accountID)
+	}
+
+	opts := &gModels.QueryOpts{Limit: -1, Page: -1}
+	found, err := repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(found.Bins))
+
+	opts.Limit = 10
+	opts.Page = 0
+	found, err = repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, opts.Limit, len(found.Bins))
+	assert.NotEqual(t, "", found.Next, "Expected a next page")
+	assert.Equal(t, "", found.Prev, "Expected no previous page")
+
+	opts.Limit = 10
+	opts.Page = 2
+	found, err = repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(found.Bins))
+	assert.Equal(t, "", found.Next, "Expected no next page")
+	assert.NotEqual(t, "", found.Prev, "Expected a previous page")
+	assert.Nil(t, found.Total, "Expected no total unless requested")
+}
+
+func testGetAllOrder(t *testing.T, repo bins.DB, accountID string) {
+	var created []*models.Bin
+	for i := 0; i < 6; i++ {
+		created = append(created, testCreateBin(repo, accountID))
+		time.Sleep(time.Millisecond)
+	}
+
+	var listed []*models.Bin
+	for page := 0; page < 3; page++ {
+		found, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 2, Page: page})
+		assert.Nil(t, err)
+		listed = append(listed, found.Bins...)
+	}
+
+	assert.Equal(t, len(created), len(listed))
+	for i := range created {
+		assert.Equal(t, created[i].ID, listed[i].ID, "Expected oldest first without overlapping pages")
+	}
+}
+
+func testGetAllCursor(t *testing.T, repo bins.DB, accountID string) {
+	var created []string
+	for i := 0; i < 7; i++ {
+		created = append(created, testCreateBin(repo, accountID).ID)
+		time.Sleep(time.Millisecond)
+	}
+
+	// newest first, walking forward through the next cursors
+	opts := &gModels.QueryOpts{Limit: 3, Sort: models.SortCreatedAt, Order: gModels.OrderDesc}
+	var pages []*models.Page
+	for {
+		page, err := repo.GetAll(ctx, accountID, opts)
+		assert.Nil(t, err)
+		pages = append(pages, page)
+		if page.Next == "" || len(pages) > 3 {
+			break
+		}
+		opts.Cursor, err = gModels.DecodeCursor(page.Next)
+		assert.Nil(t, err)
+	}
+
+	var listed []string
+	for _, page := range pages {
+		for _, bin := range page.Bins {
+			listed = append(listed, bin.ID)
+		}
+	}
+	assert.Equal(t, []string{created[6], created[5], created[4], created[3], created[2], created[1], created[0]}, listed)
+	assert.Equal(t, 3, len(pages))
+	assert.Equal(t, "", pages[0].Prev, "Expected no page before the first")
+
+	// walking back through the previous cursors returns the same pages
+	cursor, err := gModels.DecodeCursor(pages[2].Prev)
+	assert.Nil(t, err)
+	opts.Cursor = cursor
+	page, err := repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, pages[1].Bins, page.Bins)
+	assert.NotEqual(t, "", page.Next)
+
+	opts.Cursor, _ = gModels.DecodeCursor(page.Prev)
+	page, err = repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, pages[0].Bins, page.Bins)
+	assert.Equal(t, "", page.Prev, "Expected no page before the first")
+
+	// bins created meanwhile do not shift the following page
+	testCreateBin(repo, accountID)
+	opts.Cursor, _ = gModels.DecodeCursor(pages[0].Next)
+	page, err = repo.GetAll(ctx, accountID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, pages[1].Bins, page.Bins)
+}
+
+func testGetAllSort(t *testing.T, repo bins.DB, accountID string) {
+	titles := []string{"delta", "alpha", "charlie", "echo", "charlie", "bravo"}
+	byTitle := map[string][]string{}
+	for _, title := range titles {
+		bin := &models.Bin{Title: title}
+		assert.Nil(t, repo.Create(ctx, accountID, bin))
+		byTitle[title] = append(byTitle[title], bin.ID)
+	}
+	sort.Strings(byTitle["charlie"])
+
+	opts := &gModels.QueryOpts{Limit: 2, Sort: models.SortTitle, Order: gModels.OrderAsc}
+	var listed []string
+	for i := 0; i < 3; i++ {
+		page, err := repo.GetAll(ctx, accountID, opts)
+		assert.Nil(t, err)
+		for _, bin := range page.Bins {
+			listed = append(listed, bin.Title+" "+bin.ID)
+		}
+		opts.Cursor, _ = gModels.DecodeCursor(page.Next)
+	}
+	assert.Equal(t, []string{
+		"alpha " + byTitle["alpha"][0],
+		"bravo " + byTitle["bravo"][0],
+		"charlie " + byTitle["charlie"][0],
+		"charlie " + byTitle["charlie"][1],
+		"delta " + byTitle["delta"][0],
+		"echo " + byTitle["echo"][0],
+	}, listed, "Expected titles tied on the sort to be ordered by ID")
+
+	page, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 1, Sort: models.SortTitle, Order: gModels.OrderDesc})
+	assert.Nil(t, err)
+	assert.Equal(t, "echo", page.Bins[0].Title)
+
+	// the last updated bin comes last
+	time.Sleep(time.Millisecond)
+	bin := &models.Bin{Title: "alpha"}
+	_, err = repo.Update(ctx, accountID, byTitle["alpha"][0], bin)
+	assert.Nil(t, err)
+	page, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 1, Sort: models.SortUpdatedAt, Order: gModels.OrderDesc})
+	assert.Nil(t, err)
+	assert.Equal(t, byTitle["alpha"][0], page.Bins[0].ID)
+}
+
+func testGetAllFilters(t *testing.T, repo bins.DB, accountID string) {
+	var created []*models.Bin
+	for _, title := range []string{"Stripe events", "GitHub pushes", "stripe refunds", "100% stripe"} {
+		bin := &models.Bin{Title: title}
+		assert.Nil(t, repo.Create(ctx, accountID, bin))
+		created = append(created, bin)
+		time.Sleep(time.Millisecond)
+	}
+
+	page, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Title: "STRIPE", Total: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(page.Bins), "Expected the title to match regardless of case")
+	assert.Equal(t, 3, *page.Total)
+
+	page, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Title: "0%"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Bins), "Expected wildcards to match literally")
+
+	page, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Title: "_"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Bins), "Expected wildcards to match literally")
+
+	// the stored creation times, as precise as the database keeps them
+	all, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10})
+	assert.Nil(t, err)
+	first, last := all.Bins[0].CreatedAt, all.Bins[3].CreatedAt
+
+	page, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 1, CreatedAfter: first, CreatedBefore: last, Total: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, *page.Total, "Expected the bounds to be excluded")
+	assert.Equal(t, created[1].ID, page.Bins[0].ID)
+	assert.NotEqual(t, "", page.Next)
+}
+
+func testGetAllErrors(t *testing.T, repo bins.DB, accountID string) {
+	testCreateBin(repo, accountID)
+
+	_, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Sort: "account_id"})
+	assert.True(t, errors.Is(err, bins.ErrValidation), "Expected validation err")
+
+	cursor := &gModels.Cursor{Sort: models.SortCreatedAt, Order: gModels.OrderAsc, Value: "yesterday", ID: uuid.New().String()}
+	_, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Cursor: cursor})
+	assert.True(t, errors.Is(err, bins.ErrValidation), "Expected validation err")
+
+	cursor = &gModels.Cursor{Sort: models.SortTitle, Order: gModels.OrderAsc, Value: "a", ID: uuid.New().String()}
+	_, err = repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10, Sort: models.SortCreatedAt, Cursor: cursor})
+	assert.True(t, errors.Is(err, bins.ErrValidation), "Expected the cursor of another sort to be refused")
+}
+
+func testAccountIsolation(t *testing.T, repo bins.DB, accountID string) {
+	otherAccountID := uuid.New().String()
+	defer repo.Destroy(ctx, otherAccountID)
+
+	bin := testCreateBin(repo, accountID)
+	other := testCreateBin(repo, otherAccountID)
+
+	found, err := repo.GetAll(ctx, accountID, &gModels.QueryOpts{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(found.Bins))
+	assert.Equal(t, bin.ID, found.Bins[0].ID)
+
+	_, err = repo.Get(ctx, accountID, other.ID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+
+	_, err = repo.Update(ctx, accountID, other.ID, &models.Bin{Title: "Taken over"})
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+
+	_, err = repo.Delete(ctx, accountID, other.ID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+
+	affected, err := repo.Destroy(ctx, accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, affected)
+
+	stored, err := repo.Get(ctx, otherAccountID, other.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, other.Title, stored.Title, "Expected the other account to be untouched")
+}
+
+func testGetErrors(t *testing.T, repo bins.DB, accountID string) {
+	binID := uuid.New().String()
+
+	bin, err := repo.Get(ctx, accountID, binID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+	assert.Nil(t, bin, "Expected nil bin")
+
+	bin, err = repo.Lookup(ctx, binID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+	assert.Nil(t, bin, "Expected nil bin")
+
+	affected, err := repo.Update(ctx, accountID, binID, &models.Bin{})
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+	assert.Equal(t, 0, affected, "Expected no entry")
+
+	affected, err = repo.Delete(ctx, accountID, binID)
+	assert.True(t, errors.Is(err, bins.ErrNotFound), "Expected not found err")
+	assert.Equal(t, 0, affected, "Expected no entry")
+
+	affected, err = repo.Destroy(ctx, accountID)
+	assert.Nil(t, err, "Expected nil err")
+	assert.Equal(t, 0, affected, "Expected no entry")
+
+	err = repo.Create(ctx, accountID, &models.Bin{Title: strings.Repeat("a", 256)})
+	assert.True(t, errors.Is(err, bins.ErrValidation), "Expected validation err")
+
+	created := testCreateBin(repo, accountID)
+	_, err = repo.Update(ctx, accountID, created.ID, &models.Bin{Title: strings.Repeat("a", 256)})
+	assert.True(t, errors.Is(err, bins.ErrValidation), "Expected validation err")
+}
+
+func testCreateBin(repo bins.DB, accountID string) *models.Bin {
+	bin := &models.Bin{
+		Title: fake.ProductName(),
+	}
+	repo.Create(ctx, accountID, bin)
+	return bin
+}
+
+func testSecrets(t *testing.T, repo bins.DB, accountID string) {
+	bin := &models.Bin{
+		Title:        fake.ProductName(),
+		Handshake:    models.Handshake{Provider: "zoom", Secret: "signing"},
+		Verification: models.Verification{Provider: "github", Secret: "verifying"},
+	}
+	assert.Nil(t, repo.Create(ctx, accountID, bin))
+
+	stored, err := repo.Get(ctx, accountID, bin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "signing", stored.Handshake.Secret, "Expected the secrets to be stored")
+	assert.Equal(t, "verifying", stored.Verification.Secret, "Expected the secrets to be stored")
+}
+
+func testCanceled(t *testing.T, repo bins.DB, accountID string) {
+	bin := &models.Bin{Title: fake.ProductName()}
+	assert.Nil(t, repo.Create(ctx, accountID, bin))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	found, err := repo.Get(canceled, accountID, bin.ID)
+	assert.True(t, errors.Is(err, context.Canceled), "Expected the canceled read to fail")
+	assert.Nil(t, found)
+
+	_, err = repo.Lookup(canceled, bin.ID)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = repo.GetAll(canceled, accountID, &gModels.QueryOpts{Limit: 10})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
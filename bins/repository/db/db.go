@@ -1,14 +1,28 @@
 package db
 
 import (
-	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/metrics"
 	"github.com/jinzhu/gorm"
 )
 
-// New configures the database infrastructure
-func New(postgres *gorm.DB, redis *redis.Client) *CacheRepo {
+// New configures the database infrastructure on a Postgres or SQLite
+// connection. The calls are traced both through the cache and to the
+// database.
+func New(database *gorm.DB, cache cache.Cache, cfg config.Cache, m *metrics.Metrics) bins.DB {
+	sql := NewTracing(NewMetrics(&SQLRepo{DB: database}, m), "SQLRepo")
+	return NewTracing(NewCache(sql, cache, cfg, m), "CacheRepo")
+}
+
+// NewCache caches the bins of db
+func NewCache(db bins.DB, cache cache.Cache, cfg config.Cache, m *metrics.Metrics) *CacheRepo {
 	return &CacheRepo{
-		DB:    &PostgresRepo{DB: postgres},
-		Cache: redis,
+		DB:                  db,
+		Cache:               cache,
+		Expiration:          cfg.Expiration,
+		TombstoneExpiration: cfg.TombstoneExpiration,
+		Metrics:             m,
 	}
 }
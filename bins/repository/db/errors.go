@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	maxTitle = 255
+)
+
+// classify wraps a gorm error in the bins error kinds. Errors the database
+// reports about the statement itself are left unclassified, as is the
+// cancellation of ctx by the caller.
+func classify(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case context.Canceled:
+		return ctx.Err()
+	case context.DeadlineExceeded:
+		return bins.Wrap(bins.ErrUnavailable, ctx.Err())
+	}
+	if gorm.IsRecordNotFoundError(err) {
+		return bins.Wrap(bins.ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return classifyPostgres(err, pqErr)
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return classifySQLite(err, sqliteErr)
+	}
+
+	// the connection failed before the database could answer
+	return bins.Wrap(bins.ErrUnavailable, err)
+}
+
+func classifyPostgres(err error, pqErr *pq.Error) error {
+	switch pqErr.Code.Class() {
+	case "08", "53", "57":
+		// connection exception, insufficient resources, operator intervention
+		return bins.Wrap(bins.ErrUnavailable, err)
+	case "22":
+		// data exception
+		return bins.Wrap(bins.ErrValidation, err)
+	case "23":
+		// integrity constraint violation
+		if pqErr.Code == "23505" || pqErr.Code == "23503" {
+			return bins.Wrap(bins.ErrConflict, err)
+		}
+		return bins.Wrap(bins.ErrValidation, err)
+	}
+
+	return err
+}
+
+func classifySQLite(err error, sqliteErr sqlite3.Error) error {
+	switch sqliteErr.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrNomem, sqlite3.ErrIoErr, sqlite3.ErrCantOpen, sqlite3.ErrFull:
+		return bins.Wrap(bins.ErrUnavailable, err)
+	case sqlite3.ErrTooBig, sqlite3.ErrMismatch:
+		return bins.Wrap(bins.ErrValidation, err)
+	case sqlite3.ErrConstraint:
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintForeignKey:
+			return bins.Wrap(bins.ErrConflict, err)
+		}
+		return bins.Wrap(bins.ErrValidation, err)
+	}
+
+	return err
+}
+
+// validate enforces the constraints of the bin table that SQLite and the
+// memory repository do not
+func validate(bin *models.Bin) error {
+	if utf8.RuneCountInString(bin.Title) > maxTitle {
+		return bins.Wrap(bins.ErrValidation, errors.New("title is longer than 255 characters"))
+	}
+	return nil
+}
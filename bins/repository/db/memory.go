@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+)
+
+// MemoryRepo keeps the bins in memory. It backs the dev mode, so the bins are
+// lost on restart.
+type MemoryRepo struct {
+	mu   sync.RWMutex
+	bins map[string]*models.Bin
+}
+
+// NewMemory ...
+func NewMemory() *MemoryRepo {
+	return &MemoryRepo{bins: map[string]*models.Bin{}}
+}
+
+// GetAll returns a page of bins for the account, ordered as SQLRepo does
+func (r *MemoryRepo) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	by, err := sortOf(opts)
+	if err != nil {
+		return nil, err
+	}
+	var at *models.Bin
+	if opts.Cursor != nil {
+		if at, err = cursorBin(by, opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var owned []*models.Bin
+	for _, bin := range r.bins {
+		if bin.AccountID == accountID && matches(bin, opts) {
+			owned = append(owned, bin)
+		}
+	}
+
+	order := 1
+	if descending(opts) {
+		order = -1
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return compare(by, owned[i], owned[j])*order < 0
+	})
+
+	start := opts.GetOffset()
+	if at != nil {
+		start = sort.Search(len(owned), func(i int) bool {
+			return compare(by, owned[i], at)*order > 0
+		})
+	}
+
+	var rows []*models.Bin
+	for i := start; i < len(owned) && len(rows) <= opts.GetLimit(); i++ {
+		rows = append(rows, clone(owned[i]))
+	}
+
+	page := newPage(by, opts, rows)
+	if opts.Total {
+		total := len(owned)
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// Get one bin associated with the given account id
+func (r *MemoryRepo) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bin, ok := r.bins[ID]
+	if !ok || bin.AccountID != accountID {
+		return nil, bins.ErrNotFound
+	}
+
+	return clone(bin), nil
+}
+
+// Lookup returns one bin regardless of the account it belongs to
+func (r *MemoryRepo) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bin, ok := r.bins[ID]
+	if !ok {
+		return nil, bins.ErrNotFound
+	}
+
+	return clone(bin), nil
+}
+
+// Create stores a new bin
+func (r *MemoryRepo) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	if err := validate(bin); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	bin.AccountID = accountID
+	bin.ID = uuid.New().String()
+	bin.Response.Status = bin.Response.StatusCode()
+	bin.CreatedAt = &now
+	bin.UpdatedAt = &now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bins[bin.ID] = clone(bin)
+	return nil
+}
+
+// Update replaces the bin with the provided values
+func (r *MemoryRepo) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	if err := validate(bin); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.bins[ID]
+	if !ok || stored.AccountID != accountID {
+		return 0, bins.ErrNotFound
+	}
+
+	now := time.Now()
+	updated := clone(bin)
+	updated.ID = stored.ID
+	updated.AccountID = stored.AccountID
+	updated.Response.Status = updated.Response.StatusCode()
+	updated.CreatedAt = stored.CreatedAt
+	updated.UpdatedAt = &now
+
+	r.bins[ID] = updated
+	return 1, nil
+}
+
+// Delete removes a bin associated with the account
+func (r *MemoryRepo) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.bins[ID]
+	if !ok || stored.AccountID != accountID {
+		return 0, bins.ErrNotFound
+	}
+
+	delete(r.bins, ID)
+	return 1, nil
+}
+
+// Destroy removes all bins associated with the account
+func (r *MemoryRepo) Destroy(ctx context.Context, accountID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	affected := 0
+	for ID, bin := range r.bins {
+		if bin.AccountID == accountID {
+			delete(r.bins, ID)
+			affected++
+		}
+	}
+
+	return affected, nil
+}
+
+// matches applies the title and creation time filters as SQLRepo does
+func matches(bin *models.Bin, opts *gModels.QueryOpts) bool {
+	if opts.Title != "" && !strings.Contains(strings.ToLower(bin.Title), strings.ToLower(opts.Title)) {
+		return false
+	}
+	if opts.CreatedAfter != nil && !bin.CreatedAt.After(*opts.CreatedAfter) {
+		return false
+	}
+	if opts.CreatedBefore != nil && !bin.CreatedAt.Before(*opts.CreatedBefore) {
+		return false
+	}
+
+	return true
+}
+
+// clone copies the bin so that callers never share the stored maps and
+// slices. The secrets are hidden from json and copied over.
+func clone(bin *models.Bin) *models.Bin {
+	marshalled, _ := json.Marshal(bin)
+
+	copied := &models.Bin{}
+	json.Unmarshal(marshalled, copied)
+	copied.Handshake.Secret = bin.Handshake.Secret
+	copied.Verification.Secret = bin.Verification.Secret
+	return copied
+}
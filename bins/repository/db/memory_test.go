@@ -0,0 +1,11 @@
+package db_test
+
+import (
+	"testing"
+
+	binsDB "github.com/hugocortes/hooks-api/bins/repository/db"
+)
+
+func TestMemory(t *testing.T) {
+	testConformance(t, binsDB.NewMemory())
+}
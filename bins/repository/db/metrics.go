@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/metrics"
+	gModels "github.com/hugocortes/hooks-api/models"
+)
+
+// MetricsRepo observes the latency and the errors of every call to DB
+type MetricsRepo struct {
+	DB      bins.DB
+	Metrics *metrics.Metrics
+}
+
+// NewMetrics observes db, or returns db as is when metrics are disabled
+func NewMetrics(db bins.DB, m *metrics.Metrics) bins.DB {
+	if m == nil {
+		return db
+	}
+	return &MetricsRepo{DB: db, Metrics: m}
+}
+
+// GetAll ...
+func (r *MetricsRepo) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	start := time.Now()
+	page, err := r.DB.GetAll(ctx, accountID, opts)
+	r.Metrics.DB("GetAll", start, kind(err))
+
+	return page, err
+}
+
+// Get ...
+func (r *MetricsRepo) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	start := time.Now()
+	bin, err := r.DB.Get(ctx, accountID, ID)
+	r.Metrics.DB("Get", start, kind(err))
+
+	return bin, err
+}
+
+// Lookup ...
+func (r *MetricsRepo) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	start := time.Now()
+	bin, err := r.DB.Lookup(ctx, ID)
+	r.Metrics.DB("Lookup", start, kind(err))
+
+	return bin, err
+}
+
+// Create ...
+func (r *MetricsRepo) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	start := time.Now()
+	err := r.DB.Create(ctx, accountID, bin)
+	r.Metrics.DB("Create", start, kind(err))
+
+	return err
+}
+
+// Update ...
+func (r *MetricsRepo) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	start := time.Now()
+	affected, err := r.DB.Update(ctx, accountID, ID, bin)
+	r.Metrics.DB("Update", start, kind(err))
+
+	return affected, err
+}
+
+// Delete ...
+func (r *MetricsRepo) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	start := time.Now()
+	affected, err := r.DB.Delete(ctx, accountID, ID)
+	r.Metrics.DB("Delete", start, kind(err))
+
+	return affected, err
+}
+
+// Destroy ...
+func (r *MetricsRepo) Destroy(ctx context.Context, accountID string) (int, error) {
+	start := time.Now()
+	affected, err := r.DB.Destroy(ctx, accountID)
+	r.Metrics.DB("Destroy", start, kind(err))
+
+	return affected, err
+}
+
+// kind names the kind of err for the error counters, empty for no error
+func kind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, bins.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, bins.ErrConflict):
+		return "conflict"
+	case errors.Is(err, bins.ErrValidation):
+		return "validation"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.Is(err, bins.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
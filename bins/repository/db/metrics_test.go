@@ -0,0 +1,37 @@
+package db_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins/models"
+	binsDB "github.com/hugocortes/hooks-api/bins/repository/db"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func testScrape(m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(config.Default().Metrics)
+	repo := binsDB.NewMetrics(binsDB.NewMemory(), m)
+	testConformance(t, repo)
+
+	accountID := uuid.New().String()
+	repo.Get(ctx, accountID, uuid.New().String())
+	repo.Create(ctx, accountID, &models.Bin{Title: strings.Repeat("a", 256)})
+
+	scraped := testScrape(m)
+	assert.Contains(t, scraped, `hooks_bins_db_duration_seconds_count{method="Lookup"}`)
+	assert.Contains(t, scraped, `hooks_bins_db_errors_total{kind="not_found",method="Get"}`)
+	assert.Contains(t, scraped, `hooks_bins_db_errors_total{kind="validation",method="Create"}`)
+
+	assert.Equal(t, repo, binsDB.NewMetrics(repo, nil), "Expected no decorator without metrics")
+}
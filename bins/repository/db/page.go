@@ -0,0 +1,156 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+)
+
+var errCursor = bins.Wrap(bins.ErrValidation, errors.New("cursor is invalid"))
+
+// sortOf returns the sort field of the listing, by creation time unless set.
+// The cursor must have been returned for the same sort.
+func sortOf(opts *gModels.QueryOpts) (string, error) {
+	by := opts.Sort
+	switch by {
+	case "":
+		by = models.SortCreatedAt
+	case models.SortCreatedAt, models.SortUpdatedAt, models.SortTitle:
+	default:
+		return "", bins.Wrap(bins.ErrValidation, errors.New("sort "+by+" is unknown"))
+	}
+
+	if opts.Cursor != nil && opts.Cursor.Sort != by {
+		return "", errCursor
+	}
+
+	return by, nil
+}
+
+// backward tells whether the page ends before the cursor, in which case the
+// rows are read in the reverse order of the listing
+func backward(opts *gModels.QueryOpts) bool {
+	return opts.Cursor != nil && opts.Cursor.Before
+}
+
+// descending tells whether the rows are read in descending order
+func descending(opts *gModels.QueryOpts) bool {
+	return opts.Descending() != backward(opts)
+}
+
+// field returns the value of the sort field of the bin
+func field(by string, bin *models.Bin) interface{} {
+	switch by {
+	case models.SortTitle:
+		return bin.Title
+	case models.SortUpdatedAt:
+		return timeOf(bin.UpdatedAt)
+	}
+
+	return timeOf(bin.CreatedAt)
+}
+
+// compare orders a and b by the sort field, then by ID
+func compare(by string, a *models.Bin, b *models.Bin) int {
+	var cmp int
+	switch left := field(by, a).(type) {
+	case string:
+		cmp = strings.Compare(left, field(by, b).(string))
+	case time.Time:
+		right := field(by, b).(time.Time)
+		if left.Before(right) {
+			cmp = -1
+		} else if left.After(right) {
+			cmp = 1
+		}
+	}
+	if cmp != 0 {
+		return cmp
+	}
+
+	return strings.Compare(a.ID, b.ID)
+}
+
+// cursorBin returns a bin standing at the position of the cursor
+func cursorBin(by string, cursor *gModels.Cursor) (*models.Bin, error) {
+	bin := &models.Bin{ID: cursor.ID}
+	if by == models.SortTitle {
+		bin.Title = cursor.Value
+		return bin, nil
+	}
+
+	value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, errCursor
+	}
+	if by == models.SortUpdatedAt {
+		bin.UpdatedAt = &value
+	} else {
+		bin.CreatedAt = &value
+	}
+
+	return bin, nil
+}
+
+// newCursor returns the encoded position of the bin
+func newCursor(by string, opts *gModels.QueryOpts, bin *models.Bin, before bool) string {
+	value, ok := field(by, bin).(string)
+	if !ok {
+		value = field(by, bin).(time.Time).Format(time.RFC3339Nano)
+	}
+
+	cursor := &gModels.Cursor{Sort: by, Order: opts.Order, Value: value, ID: bin.ID, Before: before}
+	if cursor.Order == "" {
+		cursor.Order = gModels.OrderAsc
+	}
+
+	return cursor.Encode()
+}
+
+// newPage builds the page from the rows read in the scan order, one more
+// than the limit when a further page follows
+func newPage(by string, opts *gModels.QueryOpts, rows []*models.Bin) *models.Page {
+	more := len(rows) > opts.GetLimit()
+	if more {
+		rows = rows[:opts.GetLimit()]
+	}
+	if backward(opts) {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := &models.Page{Bins: rows}
+	if len(rows) == 0 {
+		return page
+	}
+
+	first, last := rows[0], rows[len(rows)-1]
+	if backward(opts) {
+		page.Next = newCursor(by, opts, last, false)
+		if more {
+			page.Prev = newCursor(by, opts, first, true)
+		}
+		return page
+	}
+
+	if more {
+		page.Next = newCursor(by, opts, last, false)
+	}
+	if opts.Cursor != nil || opts.GetOffset() > 0 {
+		page.Prev = newCursor(by, opts, first, true)
+	}
+
+	return page
+}
+
+func timeOf(value *time.Time) time.Time {
+	if value == nil {
+		return time.Time{}
+	}
+	return *value
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	tableName      = "bin"
+	defaultTimeout = 5 * time.Second
+)
+
+// SQLRepo stores the bins in Postgres or SQLite through gorm
+type SQLRepo struct {
+	DB      *gorm.DB
+	Timeout time.Duration
+}
+
+// GetAll returns a page of bins for the account. The page starts at the
+// cursor when set, at the offset otherwise, and bins tied on the sort field
+// are ordered by ID so that every cursor is a unique position.
+func (r *SQLRepo) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	by, err := sortOf(opts)
+	if err != nil {
+		return nil, err
+	}
+	var at *models.Bin
+	if opts.Cursor != nil {
+		if at, err = cursorBin(by, opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []*models.Bin
+	var total int
+
+	err = r.read(ctx, func(table *gorm.DB) error {
+		query := filter(table.Where("account_id = ?", accountID), opts)
+		if opts.Total {
+			if err := query.Count(&total).Error; err != nil {
+				return err
+			}
+		}
+
+		direction, cmp := "asc", ">"
+		if descending(opts) {
+			direction, cmp = "desc", "<"
+		}
+
+		if at != nil {
+			value := field(by, at)
+			query = query.Where(fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", by, cmp), value, value, at.ID)
+		} else {
+			query = query.Offset(opts.GetOffset())
+		}
+
+		return query.Order(fmt.Sprintf("%[1]s %[2]s, id %[2]s", by, direction)).
+			Limit(opts.GetLimit() + 1).Find(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := newPage(by, opts, rows)
+	if opts.Total {
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// Get one bin associated with the given account id
+func (r *SQLRepo) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	bin := &models.Bin{}
+
+	err := r.read(ctx, func(table *gorm.DB) error {
+		return table.Where("id = ? AND account_id = ?", ID, accountID).First(bin).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bin, nil
+}
+
+// Lookup returns one bin regardless of the account it belongs to
+func (r *SQLRepo) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	bin := &models.Bin{}
+
+	err := r.read(ctx, func(table *gorm.DB) error {
+		return table.Where("id = ?", ID).First(bin).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bin, nil
+}
+
+// Create inserts a new bin to the table
+func (r *SQLRepo) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	if err := validate(bin); err != nil {
+		return err
+	}
+
+	bin.AccountID = accountID
+	bin.ID = uuid.New().String()
+
+	return r.transaction(ctx, func(table *gorm.DB) error {
+		return table.Create(bin).Error
+	})
+}
+
+// Update updates the bin with the provided values
+func (r *SQLRepo) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	if err := validate(bin); err != nil {
+		return 0, err
+	}
+
+	var rows int64
+
+	err := r.transaction(ctx, func(table *gorm.DB) error {
+		res := table.Model(&models.Bin{}).Where("id = ? AND account_id = ?", ID, accountID).Updates(updatable(bin))
+		rows = res.RowsAffected
+		return res.Error
+	})
+
+	return affected(rows, err)
+}
+
+// Delete removes a bin associated with the account
+func (r *SQLRepo) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	var rows int64
+
+	err := r.transaction(ctx, func(table *gorm.DB) error {
+		res := table.Where("id = ? AND account_id = ?", ID, accountID).Delete(&models.Bin{})
+		rows = res.RowsAffected
+		return res.Error
+	})
+
+	return affected(rows, err)
+}
+
+// Destroy removes all bins associated with the account
+func (r *SQLRepo) Destroy(ctx context.Context, accountID string) (int, error) {
+	var rows int64
+
+	err := r.transaction(ctx, func(table *gorm.DB) error {
+		res := table.Where("account_id = ?", accountID).Delete(&models.Bin{})
+		rows = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+// read runs the query on the bin table in a read only transaction, bound to
+// ctx and the operation timeout like the writes
+func (r *SQLRepo) read(ctx context.Context, op func(table *gorm.DB) error) error {
+	return r.run(ctx, &sql.TxOptions{ReadOnly: true}, op)
+}
+
+// transaction runs the write on the bin table in a transaction bound to ctx
+// and the operation timeout
+func (r *SQLRepo) transaction(ctx context.Context, op func(table *gorm.DB) error) error {
+	return r.run(ctx, nil, op)
+}
+
+// run runs the operation in a transaction bound to ctx and the operation
+// timeout. gorm v1 statements take no context, so the timeout is enforced by
+// Postgres as well through statement_timeout. SQLite interrupts the statements
+// itself once ctx is done.
+func (r *SQLRepo) run(ctx context.Context, options *sql.TxOptions, op func(table *gorm.DB) error) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx := r.DB.BeginTx(ctx, options)
+	if tx.Error != nil {
+		return classify(ctx, tx.Error)
+	}
+
+	var err error
+	if r.DB.Dialect().GetName() == "postgres" {
+		err = tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())).Error
+	}
+	if err == nil {
+		err = op(tx.Table(tableName))
+	}
+	if err != nil {
+		tx.Rollback()
+		return classify(ctx, err)
+	}
+
+	return classify(ctx, tx.Commit().Error)
+}
+
+// filter narrows the query to the bins matching the title and creation time
+// filters. The title matches case insensitively.
+func filter(query *gorm.DB, opts *gModels.QueryOpts) *gorm.DB {
+	if opts.Title != "" {
+		query = query.Where(`LOWER(title) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(opts.Title))+"%")
+	}
+	if opts.CreatedAfter != nil {
+		query = query.Where("created_at > ?", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *opts.CreatedBefore)
+	}
+
+	return query
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// affected returns the rows affected by a statement on a single bin, which
+// is not found when no row was affected
+func affected(rows int64, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, bins.ErrNotFound
+	}
+
+	return int(rows), nil
+}
+
+// updatable lists every column replaced by Update, zero values included
+func updatable(bin *models.Bin) map[string]interface{} {
+	return map[string]interface{}{
+		"title":                  bin.Title,
+		"response_status":        bin.Response.StatusCode(),
+		"response_headers":       bin.Response.Headers,
+		"response_body":          bin.Response.Body,
+		"response_content_type":  bin.Response.ContentType,
+		"response_delay":         bin.Response.Delay,
+		"response_timeout":       bin.Response.Timeout,
+		"response_template":      bin.Response.Template,
+		"scenario_mode":          bin.Scenario.Mode,
+		"scenario_steps":         bin.Scenario.Steps,
+		"scenario_failure_rate":  bin.Scenario.FailureRate,
+		"handshake_provider":     bin.Handshake.Provider,
+		"handshake_secret":       bin.Handshake.Secret,
+		"verification_provider":  bin.Verification.Provider,
+		"verification_secret":    bin.Verification.Secret,
+		"verification_tolerance": bin.Verification.Tolerance,
+		"verification_reject":    bin.Verification.Reject,
+	}
+}
@@ -0,0 +1,22 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	binsDB "github.com/hugocortes/hooks-api/bins/repository/db"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/migrations"
+)
+
+func TestSQLite(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "hooks.db")
+
+	db := deps.SQLite(cfg)
+	defer db.Close()
+
+	migrations.Run(db)
+	testConformance(t, &binsDB.SQLRepo{DB: db})
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/tracing"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingRepo traces every call to DB under a span named after Layer and
+// the method, such as CacheRepo.Get
+type TracingRepo struct {
+	DB    bins.DB
+	Layer string
+}
+
+// NewTracing traces the calls to db
+func NewTracing(db bins.DB, layer string) *TracingRepo {
+	return &TracingRepo{DB: db, Layer: layer}
+}
+
+// GetAll ...
+func (r *TracingRepo) GetAll(ctx context.Context, accountID string, opts *gModels.QueryOpts) (*models.Page, error) {
+	ctx, span := r.start(ctx, "GetAll", attribute.String("account.id", accountID))
+	page, err := r.DB.GetAll(ctx, accountID, opts)
+	r.end(span, err)
+
+	return page, err
+}
+
+// Get ...
+func (r *TracingRepo) Get(ctx context.Context, accountID string, ID string) (*models.Bin, error) {
+	ctx, span := r.start(ctx, "Get", attribute.String("account.id", accountID), attribute.String("bin.id", ID))
+	bin, err := r.DB.Get(ctx, accountID, ID)
+	r.end(span, err)
+
+	return bin, err
+}
+
+// Lookup ...
+func (r *TracingRepo) Lookup(ctx context.Context, ID string) (*models.Bin, error) {
+	ctx, span := r.start(ctx, "Lookup", attribute.String("bin.id", ID))
+	bin, err := r.DB.Lookup(ctx, ID)
+	r.end(span, err)
+
+	return bin, err
+}
+
+// Create ...
+func (r *TracingRepo) Create(ctx context.Context, accountID string, bin *models.Bin) error {
+	ctx, span := r.start(ctx, "Create", attribute.String("account.id", accountID))
+	err := r.DB.Create(ctx, accountID, bin)
+	r.end(span, err)
+
+	return err
+}
+
+// Update ...
+func (r *TracingRepo) Update(ctx context.Context, accountID string, ID string, bin *models.Bin) (int, error) {
+	ctx, span := r.start(ctx, "Update", attribute.String("account.id", accountID), attribute.String("bin.id", ID))
+	affected, err := r.DB.Update(ctx, accountID, ID, bin)
+	r.end(span, err)
+
+	return affected, err
+}
+
+// Delete ...
+func (r *TracingRepo) Delete(ctx context.Context, accountID string, ID string) (int, error) {
+	ctx, span := r.start(ctx, "Delete", attribute.String("account.id", accountID), attribute.String("bin.id", ID))
+	affected, err := r.DB.Delete(ctx, accountID, ID)
+	r.end(span, err)
+
+	return affected, err
+}
+
+// Destroy ...
+func (r *TracingRepo) Destroy(ctx context.Context, accountID string) (int, error) {
+	ctx, span := r.start(ctx, "Destroy", attribute.String("account.id", accountID))
+	affected, err := r.DB.Destroy(ctx, accountID)
+	r.end(span, err)
+
+	return affected, err
+}
+
+func (r *TracingRepo) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, r.Layer+"."+method, attrs...)
+}
+
+// end ends the span. A missing bin is an answer rather than a failure.
+func (r *TracingRepo) end(span trace.Span, err error) {
+	if errors.Is(err, bins.ErrNotFound) {
+		span.SetAttributes(attribute.Bool("bin.found", false))
+		err = nil
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,38 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins/models"
+	binsDB "github.com/hugocortes/hooks-api/bins/repository/db"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	repo := binsDB.NewTracing(binsDB.NewMemory(), "MemoryRepo")
+	testConformance(t, repo)
+
+	accountID := uuid.New().String()
+	bin := &models.Bin{Title: "traced"}
+	assert.Nil(t, repo.Create(ctx, accountID, bin))
+	repo.Get(ctx, accountID, uuid.New().String())
+	repo.Update(ctx, accountID, bin.ID, &models.Bin{Title: strings.Repeat("a", 256)})
+
+	spans := recorder.Ended()
+	spans = spans[len(spans)-3:]
+	assert.Equal(t, "MemoryRepo.Create", spans[0].Name())
+	assert.Equal(t, "MemoryRepo.Get", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code, "Expected a missing bin not to fail the span")
+	assert.Equal(t, "MemoryRepo.Update", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
@@ -4,12 +4,25 @@ import (
 	"github.com/go-redis/redis"
 	"github.com/hugocortes/hooks-api/bins"
 	"github.com/hugocortes/hooks-api/bins/repository/db"
+	"github.com/hugocortes/hooks-api/bins/repository/sequence"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/metrics"
 	"github.com/jinzhu/gorm"
 )
 
 // New ...
-func New(postgres *gorm.DB, redis *redis.Client) *bins.Repository {
+func New(database *gorm.DB, redis *redis.Client, cache cache.Cache, cfg config.Cache, m *metrics.Metrics) *bins.Repository {
 	return &bins.Repository{
-		DB: db.New(postgres, redis),
+		DB:       db.New(database, cache, cfg, m),
+		Sequence: sequence.New(redis),
+	}
+}
+
+// NewMemory keeps the bins and scenario positions in memory
+func NewMemory(cache cache.Cache, cfg config.Cache, m *metrics.Metrics) *bins.Repository {
+	return &bins.Repository{
+		DB:       db.NewTracing(db.NewCache(db.NewMetrics(db.NewMemory(), m), cache, cfg, m), "CacheRepo"),
+		Sequence: sequence.NewMemory(),
 	}
 }
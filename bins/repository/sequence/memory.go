@@ -0,0 +1,32 @@
+package sequence
+
+import "sync"
+
+// MemorySequence keeps the scenario positions of a single instance
+type MemorySequence struct {
+	mu        sync.Mutex
+	positions map[string]int64
+}
+
+// NewMemory ...
+func NewMemory() *MemorySequence {
+	return &MemorySequence{positions: map[string]int64{}}
+}
+
+// Next increments and returns the 1-based position of the bin
+func (s *MemorySequence) Next(binID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.positions[binID]++
+	return s.positions[binID], nil
+}
+
+// Reset starts the scenario of the bin over
+func (s *MemorySequence) Reset(binID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.positions, binID)
+	return nil
+}
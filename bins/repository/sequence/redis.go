@@ -0,0 +1,43 @@
+package sequence
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/cache"
+)
+
+const (
+	// positions of idle bins are forgotten after a day
+	expiration = 24 * time.Hour
+)
+
+// RedisSequence keeps the scenario positions in redis so that every server
+// instance answers from the same position
+type RedisSequence struct {
+	Client *redis.Client
+}
+
+// New ...
+func New(client *redis.Client) *RedisSequence {
+	return &RedisSequence{Client: client}
+}
+
+// Next atomically increments and returns the 1-based position of the bin
+func (s *RedisSequence) Next(binID string) (int64, error) {
+	key := cache.GenKey("Sequence", binID)
+
+	pipe := s.Client.TxPipeline()
+	incr := pipe.Incr(key)
+	pipe.Expire(key, expiration)
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Reset starts the scenario of the bin over
+func (s *RedisSequence) Reset(binID string) error {
+	return s.Client.Del(cache.GenKey("Sequence", binID)).Err()
+}
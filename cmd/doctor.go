@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/common/health"
+	"github.com/spf13/cobra"
+)
+
+var doctorJSON bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks the dependencies of the server",
+	Long:  "This command runs the readiness checks of the server from the terminal and exits with 1 when one fails",
+	Run: func(cmd *cobra.Command, args []string) {
+		if code := doctor(cmd); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+// doctor prints the report and returns the exit code, once every connection
+// it opened is closed
+func doctor(cmd *cobra.Command) int {
+	cfg := load(cmd)
+	deps.ConfigureLog(cfg)
+	if cfg.Dev.Enabled {
+		fmt.Println("dev mode keeps every repository in memory, there is nothing to check")
+		return 0
+	}
+
+	redis := deps.Redis(cfg)
+	defer redis.Close()
+
+	var checker *health.Checker
+	database, err := deps.OpenDatabase(cfg)
+	if err != nil {
+		checker = deps.ChecksUnopened(cfg, err, redis)
+	} else {
+		defer database.Close()
+		checker = deps.Checks(cfg, database, redis)
+	}
+
+	report := checker.Run(context.Background())
+	if doctorJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if report.Status != health.StatusOK {
+		return 1
+	}
+	return 0
+}
+
+func printReport(report *health.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, result := range report.Checks {
+		mark := "✓"
+		if result.Status != health.StatusOK {
+			mark = "✗"
+		}
+		fmt.Fprintf(w, "%s %s\t%.1fms\t%s\n", mark, result.Name, result.Latency, result.Error)
+	}
+	w.Flush()
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "print the report as json")
+	RootCmd.AddCommand(doctorCmd)
+}
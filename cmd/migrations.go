@@ -1,24 +1,129 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
 	"github.com/hugocortes/hooks-api/common/deps"
 	"github.com/hugocortes/hooks-api/migrations"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var downTo string
+var createDir string
+
 var migrationsCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Runs migrations and schema initialization",
-	Long:  "This command runs all migrations required for hooks-api",
+	Long:  "This command applies every pending migration, as migrate up does",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrateUpCmd.Run(cmd, args)
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applies every pending migration",
+	Long:  "This command applies the pending migrations in order, each in its own transaction",
 	Run: func(cmd *cobra.Command, args []string) {
-		db := deps.Postgres()
-		migrations.Run(db)
+		db := migrationsDatabase(cmd)
+		defer db.Close()
+
+		applied, err := migrations.Up(db)
+		for _, ID := range applied {
+			fmt.Println("applied", ID)
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
 	},
 }
 
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Rolls back the last migration, or every migration after --to",
+	Long:  "This command rolls back the applied migrations in reverse order, each in its own transaction",
+	Run: func(cmd *cobra.Command, args []string) {
+		db := migrationsDatabase(cmd)
+		defer db.Close()
+
+		rolledBack, err := migrations.Down(db, downTo)
+		for _, ID := range rolledBack {
+			fmt.Println("rolled back", ID)
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Lists the migrations and whether they were applied",
+	Long:  "This command lists every migration in order and exits with 1 while some are pending",
+	Run: func(cmd *cobra.Command, args []string) {
+		db := migrationsDatabase(cmd)
+		defer db.Close()
+
+		states, err := migrations.Status(db)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		pending := false
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, state := range states {
+			status := "applied"
+			if !state.Applied {
+				status, pending = "pending", true
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", state.ID, state.Name, status)
+		}
+		w.Flush()
+
+		if pending {
+			os.Exit(1)
+		}
+	},
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Writes a new empty migration",
+	Long:  "This command writes a new migration, named after the current time, to fill in and build into the binary",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := migrations.Create(createDir, args[0], time.Now())
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Println(path)
+	},
+}
+
+// migrationsDatabase connects to the configured database, migrations do not
+// apply to dev mode
+func migrationsDatabase(cmd *cobra.Command) *gorm.DB {
+	cfg := load(cmd)
+	if cfg.Dev.Enabled {
+		logrus.Fatal("dev mode keeps every repository in memory, there is nothing to migrate")
+	}
+	deps.ConfigureLog(cfg)
+
+	// migrations may take longer than the statements serving requests
+	cfg.Postgres.StatementTimeout = 0
+
+	return deps.Database(cfg)
+}
+
 func init() {
-	RootCmd.AddCommand(migrationsCmd)
+	migrateDownCmd.Flags().StringVar(&downTo, "to", "", "ID of the last migration to keep")
+	migrateCreateCmd.Flags().StringVar(&createDir, "dir", "migrations", "directory of the migrations package")
 
-	deps.LoadEnv()
-	deps.ConfigureLog()
+	migrationsCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateCreateCmd)
+	RootCmd.AddCommand(migrationsCmd)
 }
@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"log"
-
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +17,19 @@ var RootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		logrus.Fatal(err)
+	}
+}
+
+func init() {
+	config.BindFlags(RootCmd.PersistentFlags())
+}
+
+// load reads the configuration of the command, exiting on invalid settings
+func load(cmd *cobra.Command) *config.Config {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		logrus.Fatal(err)
 	}
+	return cfg
 }
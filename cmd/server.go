@@ -1,40 +1,113 @@
 package cmd
 
 import (
-	"os"
+	"context"
+	"errors"
+	"net/http"
 
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/bins"
 	_binsHandlers "github.com/hugocortes/hooks-api/bins/handlers"
 	_binsInterfaces "github.com/hugocortes/hooks-api/bins/interfaces"
 	_binsRepository "github.com/hugocortes/hooks-api/bins/repository"
+	"github.com/hugocortes/hooks-api/common/cache"
 	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/metrics"
 	"github.com/hugocortes/hooks-api/common/middleware"
+	"github.com/hugocortes/hooks-api/common/server"
+	"github.com/hugocortes/hooks-api/common/tracing"
+	"github.com/hugocortes/hooks-api/requests"
+	_requestsHandlers "github.com/hugocortes/hooks-api/requests/handlers"
+	_requestsInterfaces "github.com/hugocortes/hooks-api/requests/interfaces"
+	_requestsRepository "github.com/hugocortes/hooks-api/requests/repository"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// devCacheSize bounds the bin cache of dev mode when CACHE_L1_SIZE is zero,
+// which would otherwise grow without limit
+const devCacheSize = 10000
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Starts the server",
 	Long:  "This command starts the server",
 	Run: func(cmd *cobra.Command, args []string) {
-		deps.LoadEnv()
-		deps.ConfigureLog()
+		cfg := load(cmd)
+		deps.ConfigureLog(cfg)
+
+		flush, err := tracing.Setup(context.Background(), cfg.Tracing)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		router := deps.Router(cfg)
+		m := metrics.New(cfg.Metrics)
+		router.Use(tracing.Middleware(cfg.Tracing.ServiceName), logging.Middleware(), m.Middleware())
+		middle := middleware.New(router, cfg.IDP)
+		srv := server.New(router, cfg)
+		// the spans are flushed last, once every other dependency closed
+		srv.OnClose("tracing", flush)
+
+		var authenticated *gin.RouterGroup
+		var binRepo *bins.Repository
+		var requestRepo *requests.Repository
+		if cfg.Dev.Enabled {
+			logrus.Warn("dev mode: data is kept in memory and every request is authenticated as account ", cfg.Dev.AccountID)
 
-		router := deps.Router()
-		postgres := deps.Postgres()
-		redis := deps.Redis()
+			size := cfg.Cache.L1Size
+			if size <= 0 {
+				size = devCacheSize
+			}
+
+			authenticated = router.Group("", middleware.Static(cfg.Dev.AccountID))
+			binRepo = _binsRepository.NewMemory(cache.NewMemory(size), cfg.Cache, m)
+			requestRepo = _requestsRepository.NewMemory(m)
+		} else {
+			database := deps.Database(cfg)
+			redis := deps.Redis(cfg)
+			srv.OnClose("database", database.Close)
+			srv.OnClose("redis", redis.Close)
+			srv.Checks = deps.Checks(cfg, database, redis)
+			m.Database(cfg.Database.Driver, database.DB())
+
+			authenticate, err := middle.Authenticate()
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			authenticated = router.Group("", authenticate)
+			binRepo = _binsRepository.New(database, redis, deps.Cache(srv.Context(), redis, cfg), cfg.Cache, m)
+			requestRepo = _requestsRepository.New(database, redis, m)
+		}
 
 		// Bin initialization
-		binRepo := _binsRepository.New(postgres, redis)
 		binHandler := _binsHandlers.New(binRepo)
 		binInter := _binsInterfaces.New(binHandler)
-		binInter.AddRoutes(router)
+		binInter.AddRoutes(authenticated)
+
+		// Captured request initialization
+		requestHandler := _requestsHandlers.New(requestRepo, binRepo, cfg.Replay.Networks())
+		requestInter := _requestsInterfaces.New(requestHandler, cfg.Stream.AllowedOrigins, cfg.Proxies.Networks())
+		requestInter.AddRoutes(router, authenticated)
+		srv.OnStop(requestInter.Stop)
+
+		// probes
+		router.GET("/healthz", srv.Live)
+		router.GET("/readyz", srv.Ready)
+		if m != nil {
+			router.GET("/metrics", gin.WrapH(m.Handler()))
+		}
 
 		// start http
-		middle := middleware.New(router)
 		router.NoRoute(middle.NotFound)
 		router.Use(middle.CorsConfig())
-		middle.Auth()
-		router.Run(":" + os.Getenv("PORT"))
+		if !cfg.Dev.Enabled {
+			middle.Auth()
+		}
+		if err := srv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatal(err)
+		}
 	},
 }
 
@@ -1,41 +1,78 @@
 package cache
 
 import (
-	"os"
+	"context"
+	"errors"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-const (
-	defaultExpiration = "60000ms"
-)
-
-// Prefix ...
-func Prefix() string {
-	return os.Getenv("REDIS_KEY")
-}
+// ErrMiss is returned by Get when nothing is cached under the key
+var ErrMiss = errors.New("cache miss")
 
-// Expiration ...
-func Expiration() time.Duration {
-	expire := os.Getenv("REDIS_EXPIRE_LOW") + "ms"
-	if expire == "" {
-		expire = defaultExpiration
-	}
+var prefix string
 
-	duration, err := time.ParseDuration(expire)
-	if err != nil {
-		logrus.Panic("invalid cache expiration time: " + err.Error())
-	}
+// Cache stores entries until they expire. A zero expiration never expires.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	// SetNX sets the entry unless the key exists and reports whether it did
+	SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error)
+	// Incr increments the counter under the key and restarts its expiration
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	Del(ctx context.Context, keys ...string) error
+	// CompareAndDelete deletes the entry only while it holds the value
+	CompareAndDelete(ctx context.Context, key string, value []byte) error
+}
 
-	return duration
+// SetPrefix namespaces every key generated afterwards, so that several
+// deployments can share a redis
+func SetPrefix(keyPrefix string) {
+	prefix = keyPrefix
 }
 
 // GenKey ...
 func GenKey(funcName string, args ...string) string {
-	newString := Prefix() + ":"
+	newString := prefix + ":"
 	for _, arg := range args {
 		newString += arg + ":"
 	}
 	return newString + funcName
 }
+
+// Process runs the command unless ctx is done first or the timeout elapses.
+// go-redis cannot cancel a command in flight, so an abandoned command still
+// completes in the background and its result must not be read.
+// Every command is traced, a missing key is not a failure.
+func Process(ctx context.Context, client *redis.Client, timeout time.Duration, cmd redis.Cmder) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, span := tracing.Start(ctx, "redis "+cmd.Name(), attribute.String("db.system", "redis"))
+	defer func() {
+		if err == redis.Nil {
+			tracing.End(span, nil)
+			return
+		}
+		tracing.End(span, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WithContext(ctx).Process(cmd)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
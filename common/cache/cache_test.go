@@ -0,0 +1,154 @@
+package cache_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSilentRedis accepts connections and never answers
+func testSilentRedis(t *testing.T) *redis.Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	return redis.NewClient(&redis.Options{
+		Addr:        listener.Addr().String(),
+		ReadTimeout: time.Minute,
+	})
+}
+
+func TestProcessTimeout(t *testing.T) {
+	client := testSilentRedis(t)
+
+	start := time.Now()
+	err := cache.Process(context.Background(), client, 50*time.Millisecond, redis.NewStringCmd("get", "key"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second, "Command was not abandoned")
+}
+
+func TestProcessCancelled(t *testing.T) {
+	client := testSilentRedis(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := cache.Process(ctx, client, time.Minute, redis.NewStringCmd("get", "key"))
+	assert.Equal(t, context.Canceled, err)
+
+	err = cache.Process(ctx, client, time.Minute, redis.NewStringCmd("get", "key"))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	memory := cache.NewMemory(2)
+
+	memory.Set(ctx, "a", []byte("1"), 0)
+	memory.Set(ctx, "b", []byte("2"), 0)
+	memory.Get(ctx, "a")
+	memory.Set(ctx, "c", []byte("3"), 0)
+
+	_, err := memory.Get(ctx, "b")
+	assert.Equal(t, cache.ErrMiss, err, "Least recently used entry was kept")
+	value, err := memory.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, memory.Len())
+
+	memory.Set(ctx, "short", []byte("x"), 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	_, err = memory.Get(ctx, "short")
+	assert.Equal(t, cache.ErrMiss, err, "Expired entry was served")
+
+	locked, _ := memory.SetNX(ctx, "lock", []byte("one"), time.Minute)
+	assert.True(t, locked)
+	locked, _ = memory.SetNX(ctx, "lock", []byte("two"), time.Minute)
+	assert.False(t, locked)
+	memory.CompareAndDelete(ctx, "lock", []byte("two"))
+	_, err = memory.Get(ctx, "lock")
+	assert.Nil(t, err, "Lock of another owner was released")
+	memory.CompareAndDelete(ctx, "lock", []byte("one"))
+	_, err = memory.Get(ctx, "lock")
+	assert.Equal(t, cache.ErrMiss, err)
+
+	n, _ := memory.Incr(ctx, "counter", 0)
+	assert.Equal(t, int64(1), n)
+	n, _ = memory.Incr(ctx, "counter", 0)
+	assert.Equal(t, int64(2), n)
+	value, _ = memory.Get(ctx, "counter")
+	assert.Equal(t, "2", string(value))
+}
+
+// channelBus delivers every invalidation to every subscriber
+type channelBus struct {
+	subscribers []chan *cache.Invalidation
+}
+
+func (b *channelBus) Publish(ctx context.Context, invalidation *cache.Invalidation) error {
+	for _, subscriber := range b.subscribers {
+		subscriber <- invalidation
+	}
+	return nil
+}
+
+func (b *channelBus) Subscribe(ctx context.Context) (<-chan *cache.Invalidation, error) {
+	subscriber := make(chan *cache.Invalidation, 10)
+	b.subscribers = append(b.subscribers, subscriber)
+	return subscriber, nil
+}
+
+func TestTiered(t *testing.T) {
+	ctx := context.Background()
+	shared := cache.NewMemory(0)
+	bus := &channelBus{}
+
+	first := cache.NewTiered(cache.NewMemory(10), shared, bus, time.Minute)
+	second := cache.NewTiered(cache.NewMemory(10), shared, bus, time.Minute)
+	assert.Nil(t, first.Listen(ctx))
+	assert.Nil(t, second.Listen(ctx))
+
+	first.Set(ctx, "key", []byte("old"), time.Minute)
+	value, _ := second.Get(ctx, "key")
+	assert.Equal(t, "old", string(value))
+
+	// the L1 copy of second is served even though L2 changed underneath
+	shared.Set(ctx, "key", []byte("changed"), time.Minute)
+	value, _ = second.Get(ctx, "key")
+	assert.Equal(t, "old", string(value))
+
+	first.Set(ctx, "key", []byte("new"), time.Minute)
+	assert.Eventually(t, func() bool {
+		value, _ := second.Get(ctx, "key")
+		return string(value) == "new"
+	}, time.Second, 10*time.Millisecond, "L1 copy was not invalidated")
+
+	first.Del(ctx, "key")
+	assert.Eventually(t, func() bool {
+		_, err := second.Get(ctx, "key")
+		return err == cache.ErrMiss
+	}, time.Second, 10*time.Millisecond, "L1 copy outlived the delete")
+
+	second.Incr(ctx, "version", 0)
+	first.Get(ctx, "version")
+	second.Incr(ctx, "version", 0)
+	assert.Eventually(t, func() bool {
+		value, _ := first.Get(ctx, "version")
+		return string(value) == "2"
+	}, time.Second, 10*time.Millisecond, "Counter was served from L1")
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is a Cache local to the instance. The least recently used entries
+// are evicted beyond its capacity.
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory ...
+func NewMemory(capacity int) *Memory {
+	return &Memory{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get ...
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.get(key)
+	if entry == nil {
+		return nil, ErrMiss
+	}
+
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set ...
+func (m *Memory) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, value, expiration)
+	return nil
+}
+
+// SetNX ...
+func (m *Memory) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.get(key) != nil {
+		return false, nil
+	}
+
+	m.set(key, value, expiration)
+	return true, nil
+}
+
+// Incr ...
+func (m *Memory) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	if entry := m.get(key); entry != nil {
+		var err error
+		if n, err = strconv.ParseInt(string(entry.value), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	n++
+
+	m.set(key, []byte(strconv.FormatInt(n, 10)), expiration)
+	return n, nil
+}
+
+// Del ...
+func (m *Memory) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.entries[key]; ok {
+			m.remove(element)
+		}
+	}
+	return nil
+}
+
+// CompareAndDelete ...
+func (m *Memory) CompareAndDelete(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry := m.get(key); entry != nil && bytes.Equal(entry.value, value) {
+		m.remove(m.entries[key])
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included until evicted
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// get returns the live entry and marks it as recently used
+func (m *Memory) get(key string) *memoryEntry {
+	element, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && !time.Now().Before(entry.expires) {
+		m.remove(element)
+		return nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry
+}
+
+func (m *Memory) set(key string, value []byte, expiration time.Duration) {
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		entry.expires = time.Now().Add(expiration)
+	}
+
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+	for m.capacity > 0 && m.order.Len() > m.capacity {
+		m.remove(m.order.Back())
+	}
+}
+
+func (m *Memory) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
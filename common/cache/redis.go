@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTimeout = 500 * time.Millisecond
+
+	incrScript             = `local n = redis.call("incr", KEYS[1]) redis.call("pexpire", KEYS[1], ARGV[1]) return n`
+	compareAndDeleteScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) end return 0`
+)
+
+// Redis is the Cache shared by every server instance. It is also the Bus
+// that carries their invalidations.
+type Redis struct {
+	Client  *redis.Client
+	Timeout time.Duration
+}
+
+// NewRedis ...
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{Client: client}
+}
+
+// Get ...
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	get := redis.NewStringCmd("get", key)
+	err := r.process(ctx, get)
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(get.Val()), nil
+}
+
+// Set ...
+func (r *Redis) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return r.process(ctx, redis.NewStatusCmd(withExpiration([]interface{}{"set", key, value}, expiration)...))
+}
+
+// SetNX ...
+func (r *Redis) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	err := r.process(ctx, redis.NewStatusCmd(withExpiration([]interface{}{"set", key, value, "nx"}, expiration)...))
+	if err == redis.Nil {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Incr ...
+func (r *Redis) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	if expiration <= 0 {
+		incr := redis.NewIntCmd("incr", key)
+		err := r.process(ctx, incr)
+		return incr.Val(), err
+	}
+
+	incr := redis.NewCmd("eval", incrScript, 1, key, milliseconds(expiration))
+	if err := r.process(ctx, incr); err != nil {
+		return 0, err
+	}
+	return incr.Int64()
+}
+
+// Del ...
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	args := []interface{}{"del"}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	return r.process(ctx, redis.NewIntCmd(args...))
+}
+
+// CompareAndDelete ...
+func (r *Redis) CompareAndDelete(ctx context.Context, key string, value []byte) error {
+	return r.process(ctx, redis.NewCmd("eval", compareAndDeleteScript, 1, key, value))
+}
+
+// Publish sends the invalidation to every instance
+func (r *Redis) Publish(ctx context.Context, invalidation *Invalidation) error {
+	marshalled, err := json.Marshal(invalidation)
+	if err != nil {
+		return err
+	}
+
+	return r.process(ctx, redis.NewIntCmd("publish", GenKey("Invalidate"), marshalled))
+}
+
+// Subscribe returns the invalidations published until ctx is done
+func (r *Redis) Subscribe(ctx context.Context) (<-chan *Invalidation, error) {
+	pubsub := r.Client.Subscribe(GenKey("Invalidate"))
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := pubsub.Channel()
+	invalidations := make(chan *Invalidation)
+	go func() {
+		defer close(invalidations)
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				invalidation := &Invalidation{}
+				if err := json.Unmarshal([]byte(message.Payload), invalidation); err != nil {
+					logrus.Warn("dropping malformed invalidation: ", err)
+					continue
+				}
+
+				select {
+				case invalidations <- invalidation:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return invalidations, nil
+}
+
+func (r *Redis) process(ctx context.Context, cmd redis.Cmder) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return Process(ctx, r.Client, timeout, cmd)
+}
+
+func withExpiration(args []interface{}, expiration time.Duration) []interface{} {
+	if expiration <= 0 {
+		return args
+	}
+	return append(args, "px", milliseconds(expiration))
+}
+
+func milliseconds(duration time.Duration) int64 {
+	return int64(duration / time.Millisecond)
+}
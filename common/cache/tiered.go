@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Invalidation lists the keys an instance changed
+type Invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// Bus carries the invalidations between the instances
+type Bus interface {
+	Publish(ctx context.Context, invalidation *Invalidation) error
+	Subscribe(ctx context.Context) (<-chan *Invalidation, error)
+}
+
+// Tiered keeps a short lived copy of the shared L2 entries in the L1 of the
+// instance. Changes made by an instance are broadcast so the others drop
+// their copy, and until the broadcast arrives an L1 copy is at most the L1
+// expiration old. Locks and counters are only held by L2.
+type Tiered struct {
+	L1         Cache
+	L2         Cache
+	Bus        Bus
+	Expiration time.Duration
+
+	origin string
+}
+
+// NewTiered ...
+func NewTiered(l1 Cache, l2 Cache, bus Bus, expiration time.Duration) *Tiered {
+	return &Tiered{
+		L1:         l1,
+		L2:         l2,
+		Bus:        bus,
+		Expiration: expiration,
+		origin:     uuid.New().String(),
+	}
+}
+
+// Listen drops the L1 copies changed by other instances until ctx is done
+func (t *Tiered) Listen(ctx context.Context) error {
+	invalidations, err := t.Bus.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for invalidation := range invalidations {
+			if invalidation.Origin == t.origin {
+				continue
+			}
+			t.L1.Del(context.Background(), invalidation.Keys...)
+		}
+	}()
+
+	return nil
+}
+
+// Get ...
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := t.L1.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.L2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	t.L1.Set(ctx, key, value, t.Expiration)
+
+	return value, nil
+}
+
+// Set ...
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if err := t.L2.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+
+	t.L1.Set(ctx, key, value, t.l1Expiration(expiration))
+	t.publish(key)
+	return nil
+}
+
+// SetNX ...
+func (t *Tiered) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	return t.L2.SetNX(ctx, key, value, expiration)
+}
+
+// Incr ...
+func (t *Tiered) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	n, err := t.L2.Incr(ctx, key, expiration)
+	if err != nil {
+		return 0, err
+	}
+
+	t.L1.Del(ctx, key)
+	t.publish(key)
+	return n, nil
+}
+
+// Del ...
+func (t *Tiered) Del(ctx context.Context, keys ...string) error {
+	if err := t.L2.Del(ctx, keys...); err != nil {
+		return err
+	}
+
+	t.L1.Del(ctx, keys...)
+	t.publish(keys...)
+	return nil
+}
+
+// CompareAndDelete ...
+func (t *Tiered) CompareAndDelete(ctx context.Context, key string, value []byte) error {
+	return t.L2.CompareAndDelete(ctx, key, value)
+}
+
+// publish broadcasts the change regardless of the caller giving up, as the
+// other instances would keep serving their copy
+func (t *Tiered) publish(keys ...string) {
+	err := t.Bus.Publish(context.Background(), &Invalidation{Origin: t.origin, Keys: keys})
+	if err != nil {
+		logrus.Warn("cache invalidation broadcast failed: ", err)
+	}
+}
+
+func (t *Tiered) l1Expiration(expiration time.Duration) time.Duration {
+	if expiration > 0 && expiration < t.Expiration {
+		return expiration
+	}
+	return t.Expiration
+}
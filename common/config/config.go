@@ -0,0 +1,522 @@
+// Package config loads the typed configuration of every command
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DevAccountID is the account of every request served in dev mode
+	DevAccountID = "00000000-0000-4000-8000-000000000000"
+
+	configFlag = "config"
+	configEnv  = "CONFIG_FILE"
+	fileSuffix = "_FILE"
+)
+
+// Config is read in order from the defaults, the optional YAML file, the env
+// and the flags, each overriding the previous ones. Every setting is read
+// from the env variable of its env tag, or from the file named by the same
+// variable suffixed with _FILE, and from the flag named after the variable.
+// Secrets have no flag, since the command line is visible to every process.
+type Config struct {
+	Host      string   `yaml:"host" env:"HOST"`
+	Port      string   `yaml:"port" env:"PORT"`
+	LogLevel  string   `yaml:"log_level" env:"LOG_LEVEL"`
+	LogFormat string   `yaml:"log_format" env:"LOG_FORMAT"`
+	Shutdown  Shutdown `yaml:"shutdown"`
+	Dev       Dev      `yaml:"dev"`
+	IDP       IDP      `yaml:"idp"`
+	Database  Database `yaml:"database"`
+	Postgres  Postgres `yaml:"postgres"`
+	Redis     Redis    `yaml:"redis"`
+	Cache     Cache    `yaml:"cache"`
+	Metrics   Metrics  `yaml:"metrics"`
+	Tracing   Tracing  `yaml:"tracing"`
+	Replay    Replay   `yaml:"replay"`
+	Stream    Stream   `yaml:"stream"`
+	Proxies   Proxies  `yaml:"proxies"`
+}
+
+// Shutdown configures how the server stops. Readiness fails for Delay before
+// the listener closes, so that load balancers stop routing to the instance,
+// then the requests in flight have Timeout to complete.
+type Shutdown struct {
+	Delay   time.Duration `yaml:"delay" env:"SHUTDOWN_DELAY"`
+	Timeout time.Duration `yaml:"timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// Dev keeps every repository in memory and authenticates every request as
+// AccountID, so that no external service is required
+type Dev struct {
+	Enabled   bool   `yaml:"enabled" env:"DEV"`
+	AccountID string `yaml:"account_id" env:"DEV_ACCOUNT_ID"`
+}
+
+// IDP is the OpenID Connect provider authenticating the accounts
+type IDP struct {
+	URI          string `yaml:"uri" env:"IDP_URI"`
+	Realm        string `yaml:"realm" env:"IDP_REALM"`
+	ClientID     string `yaml:"client_id" env:"IDP_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"IDP_CLIENT_SECRET" secret:"true"`
+	AccountClaim string `yaml:"account_claim" env:"IDP_ACCOUNT_CLAIM"`
+}
+
+// Database selects the storage of bins and captured requests
+type Database struct {
+	Driver     string `yaml:"driver" env:"DB_DRIVER"`
+	SQLitePath string `yaml:"sqlite_path" env:"SQLITE_PATH"`
+}
+
+// Postgres connects to the database. Every statement is cancelled after
+// StatementTimeout, zero leaves them unbounded.
+type Postgres struct {
+	Host string `yaml:"host" env:"POSTGRES_HOST"`
+	Port string `yaml:"port" env:"POSTGRES_PORT"`
+	DB   string `yaml:"db" env:"POSTGRES_DB"`
+	User string `yaml:"user" env:"POSTGRES_USER"`
+	Pass string `yaml:"pass" env:"POSTGRES_PASS" secret:"true"`
+	SSL  string `yaml:"ssl" env:"POSTGRES_SSL"`
+
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT"`
+}
+
+// Redis ...
+type Redis struct {
+	Host string `yaml:"host" env:"REDIS_HOST"`
+	Auth string `yaml:"auth" env:"REDIS_AUTH" secret:"true"`
+	Key  string `yaml:"key" env:"REDIS_KEY"`
+}
+
+// Cache configures the bin cache. Durations in the env are milliseconds
+// unless they carry a unit, durations in YAML always carry one. Entries
+// expire within a day, the lifetime of the account cache versions.
+type Cache struct {
+	Expiration          time.Duration `yaml:"expiration" env:"REDIS_EXPIRE_LOW"`
+	TombstoneExpiration time.Duration `yaml:"tombstone_expiration" env:"REDIS_EXPIRE_TOMBSTONE"`
+	L1Size              int           `yaml:"l1_size" env:"CACHE_L1_SIZE"`
+	L1Expiration        time.Duration `yaml:"l1_expiration" env:"CACHE_L1_EXPIRE"`
+}
+
+// Metrics configures the prometheus metrics served on /metrics. Captures are
+// labelled with the bin for the first MaxBins bins seen, later bins share
+// the "other" label. Zero keeps the bin IDs out of the metrics.
+type Metrics struct {
+	Enabled bool `yaml:"enabled" env:"METRICS"`
+	MaxBins int  `yaml:"max_bins" env:"METRICS_MAX_BINS"`
+}
+
+// Tracing exports the spans of every request. The otlp exporter sends them
+// over http to Endpoint, OTEL_EXPORTER_OTLP_ENDPOINT or a local collector,
+// the stdout exporter prints them. The sampler is read from the standard
+// OTEL_TRACES_SAMPLER variables.
+type Tracing struct {
+	Exporter    string `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure    bool   `yaml:"insecure" env:"TRACING_INSECURE"`
+	ServiceName string `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+// Replay restricts the targets captured requests are replayed to. Loopback,
+// private, link local and unspecified addresses are refused unless they
+// belong to one of the AllowedNetworks, comma separated CIDRs in the env.
+type Replay struct {
+	AllowedNetworks []string `yaml:"allowed_networks" env:"REPLAY_ALLOWED_NETWORKS"`
+}
+
+// Networks parses the allowed networks, which Validate checked
+func (r Replay) Networks() []*net.IPNet {
+	return networks(r.AllowedNetworks)
+}
+
+// Proxies lists the networks of the reverse proxies in front of the server,
+// comma separated CIDRs in the env. Their X-Forwarded-For and
+// X-Forwarded-Proto headers are trusted, those of other clients ignored.
+type Proxies struct {
+	Trusted []string `yaml:"trusted" env:"TRUSTED_PROXIES"`
+}
+
+// Networks parses the trusted networks, which Validate checked
+func (p Proxies) Networks() []*net.IPNet {
+	return networks(p.Trusted)
+}
+
+func networks(cidrs []string) []*net.IPNet {
+	var found []*net.IPNet
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			found = append(found, network)
+		}
+	}
+
+	return found
+}
+
+// Stream restricts the browsers opening a websocket stream to the server's own
+// origin and the AllowedOrigins, comma separated in the env. The wildcard *
+// allows every origin.
+type Stream struct {
+	AllowedOrigins []string `yaml:"allowed_origins" env:"STREAM_ALLOWED_ORIGINS"`
+}
+
+// Addr returns the address the server listens on. Unless a host is set, dev
+// mode only listens on the loopback interface since it authenticates every
+// request.
+func (c *Config) Addr() string {
+	host := c.Host
+	if host == "" && c.Dev.Enabled {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, c.Port)
+}
+
+// Default returns the configuration used for every unset setting
+func Default() *Config {
+	return &Config{
+		Port:      "8080",
+		LogLevel:  "error",
+		LogFormat: "text",
+		Shutdown: Shutdown{
+			Timeout: 30 * time.Second,
+		},
+		Dev: Dev{
+			AccountID: DevAccountID,
+		},
+		Database: Database{
+			Driver:     "postgres",
+			SQLitePath: "hooks.db",
+		},
+		Postgres: Postgres{
+			Port:             "5432",
+			StatementTimeout: 5 * time.Second,
+		},
+		Cache: Cache{
+			Expiration:          60 * time.Second,
+			TombstoneExpiration: 5 * time.Second,
+			L1Size:              10000,
+			L1Expiration:        time.Second,
+		},
+		Metrics: Metrics{
+			Enabled: true,
+			MaxBins: 1000,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "hooks-api",
+		},
+	}
+}
+
+// LoadEnv loads the env variables from the .env files that exist, .env in the
+// working directory by default. Variables already set are kept.
+func LoadEnv(file ...string) error {
+	if len(file) == 0 {
+		file = []string{".env"}
+	}
+
+	for _, name := range file {
+		err := godotenv.Load(name)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not load %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// BindFlags registers the --config flag and a flag for every setting
+func BindFlags(flags *pflag.FlagSet) {
+	flags.String(configFlag, "", "YAML configuration file, "+configEnv+" by default")
+
+	for _, setting := range settings(Default()) {
+		if setting.secret {
+			continue
+		}
+
+		usage := "overrides " + setting.env
+		if setting.value.Kind() == reflect.Bool {
+			flags.Bool(setting.flag(), setting.value.Bool(), usage)
+			continue
+		}
+		flags.String(setting.flag(), "", usage)
+	}
+}
+
+// Load reads and validates the configuration. flags may be nil, otherwise
+// they must have been bound by BindFlags.
+func Load(flags *pflag.FlagSet) (*Config, error) {
+	if err := LoadEnv(); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	path := os.Getenv(configEnv)
+	if flags != nil && flags.Changed(configFlag) {
+		path, _ = flags.GetString(configFlag)
+	}
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.readEnv(); err != nil {
+		return nil, err
+	}
+	if flags != nil {
+		if err := cfg.readFlags(flags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, env string, problem string) {
+		if !ok {
+			problems = append(problems, env+" "+problem)
+		}
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port < 65536, "PORT", "must be a port number")
+
+	switch c.LogLevel {
+	case "trace", "debug", "info", "warn", "error":
+	default:
+		check(false, "LOG_LEVEL", "must be one of trace, debug, info, warn or error")
+	}
+
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		check(false, "LOG_FORMAT", "must be text or json")
+	}
+
+	check(c.Shutdown.Delay >= 0, "SHUTDOWN_DELAY", "must not be negative")
+	check(c.Shutdown.Timeout > 0, "SHUTDOWN_TIMEOUT", "must be positive")
+	check(c.Postgres.StatementTimeout >= 0, "POSTGRES_STATEMENT_TIMEOUT", "must not be negative")
+	check(c.Cache.Expiration >= 0, "REDIS_EXPIRE_LOW", "must not be negative")
+	check(c.Cache.Expiration < 24*time.Hour, "REDIS_EXPIRE_LOW", "must be shorter than 24h")
+	check(c.Cache.TombstoneExpiration >= 0, "REDIS_EXPIRE_TOMBSTONE", "must not be negative")
+	check(c.Cache.TombstoneExpiration < 24*time.Hour, "REDIS_EXPIRE_TOMBSTONE", "must be shorter than 24h")
+	check(c.Cache.L1Expiration >= 0, "CACHE_L1_EXPIRE", "must not be negative")
+	check(c.Metrics.MaxBins >= 0, "METRICS_MAX_BINS", "must not be negative")
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME", "is required")
+
+	for _, cidr := range c.Replay.AllowedNetworks {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "REPLAY_ALLOWED_NETWORKS", "must list CIDRs such as 10.0.0.0/8")
+	}
+
+	for _, cidr := range c.Proxies.Trusted {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "TRUSTED_PROXIES", "must list CIDRs such as 10.0.0.0/8")
+	}
+
+	for _, origin := range c.Stream.AllowedOrigins {
+		u, err := url.Parse(origin)
+		valid := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == ""
+		check(origin == "*" || valid, "STREAM_ALLOWED_ORIGINS", "must list origins such as https://app.example.com")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		check(false, "TRACING_EXPORTER", "must be none, otlp or stdout")
+	}
+
+	if c.Dev.Enabled {
+		check(c.Dev.AccountID != "", "DEV_ACCOUNT_ID", "is required in dev mode")
+		return joined(problems)
+	}
+
+	check(c.IDP.URI != "", "IDP_URI", "is required")
+	check(c.IDP.Realm != "", "IDP_REALM", "is required")
+	check(c.IDP.ClientID != "", "IDP_CLIENT_ID", "is required")
+	check(c.IDP.ClientSecret != "", "IDP_CLIENT_SECRET", "is required")
+	check(c.Redis.Host != "", "REDIS_HOST", "is required")
+
+	switch c.Database.Driver {
+	case "postgres":
+		check(c.Postgres.Host != "", "POSTGRES_HOST", "is required")
+		check(c.Postgres.DB != "", "POSTGRES_DB", "is required")
+		check(c.Postgres.User != "", "POSTGRES_USER", "is required")
+	case "sqlite", "sqlite3":
+		check(c.Database.SQLitePath != "", "SQLITE_PATH", "is required")
+	default:
+		check(false, "DB_DRIVER", "must be postgres or sqlite")
+	}
+
+	return joined(problems)
+}
+
+// Debug reports whether the log level includes debug messages
+func (c *Config) Debug() bool {
+	return c.LogLevel == "debug" || c.LogLevel == "trace"
+}
+
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not read configuration: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("could not read configuration %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) readEnv() error {
+	for _, setting := range settings(c) {
+		value := os.Getenv(setting.env)
+		set := value != ""
+
+		if name := os.Getenv(setting.env + fileSuffix); name != "" {
+			if set {
+				return fmt.Errorf("%s and %s are both set", setting.env, setting.env+fileSuffix)
+			}
+
+			content, err := os.ReadFile(name)
+			if err != nil {
+				return fmt.Errorf("could not read %s: %w", setting.env+fileSuffix, err)
+			}
+			value, set = strings.TrimRight(string(content), "\r\n"), true
+		}
+
+		// empty variables, as in .env.example, leave the setting unset
+		if !set {
+			continue
+		}
+		if err := setting.set(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) readFlags(flags *pflag.FlagSet) error {
+	for _, setting := range settings(c) {
+		flag := flags.Lookup(setting.flag())
+		if setting.secret || flag == nil || !flag.Changed {
+			continue
+		}
+		if err := setting.set(flag.Value.String()); err != nil {
+			return fmt.Errorf("--%s: %w", setting.flag(), err)
+		}
+	}
+
+	return nil
+}
+
+// setting is a field of the configuration read from the env
+type setting struct {
+	env    string
+	value  reflect.Value
+	secret bool
+}
+
+// settings lists the fields of the configuration with an env tag
+func settings(c *Config) []setting {
+	var found []setting
+
+	var walk func(value reflect.Value)
+	walk = func(value reflect.Value) {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if env := field.Tag.Get("env"); env != "" {
+				found = append(found, setting{env: env, value: value.Field(i), secret: field.Tag.Get("secret") == "true"})
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(value.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+
+	return found
+}
+
+// flag is the env variable in lower kebab case
+func (s setting) flag() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+func (s setting) set(raw string) error {
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(raw)
+	case bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", s.env)
+		}
+		s.value.SetBool(parsed)
+	case int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", s.env)
+		}
+		s.value.SetInt(int64(parsed))
+	case []string:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		s.value.Set(reflect.ValueOf(values))
+	case time.Duration:
+		parsed, err := duration(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be milliseconds or a duration", s.env)
+		}
+		s.value.SetInt(int64(parsed))
+	default:
+		return fmt.Errorf("%s has an unsupported type", s.env)
+	}
+
+	return nil
+}
+
+// duration parses milliseconds, or a duration with a unit such as 5s
+func duration(raw string) (time.Duration, error) {
+	if milliseconds, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(milliseconds) * time.Millisecond, nil
+	}
+	return time.ParseDuration(raw)
+}
+
+func joined(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+}
@@ -0,0 +1,197 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRequired(t *testing.T) {
+	for key, value := range map[string]string{
+		"IDP_URI":           "https://idp.test",
+		"IDP_REALM":         "hooks",
+		"IDP_CLIENT_ID":     "hooks-api",
+		"IDP_CLIENT_SECRET": "secret",
+		"POSTGRES_HOST":     "localhost",
+		"POSTGRES_DB":       "hooks",
+		"POSTGRES_USER":     "hooks",
+		"REDIS_HOST":        "localhost:6379",
+	} {
+		t.Setenv(key, value)
+	}
+}
+
+func testFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func testFlags(args ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	config.BindFlags(flags)
+	flags.Parse(args)
+	return flags
+}
+
+func TestLoadDefaults(t *testing.T) {
+	testRequired(t)
+
+	cfg, err := config.Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "postgres", cfg.Database.Driver)
+	assert.Equal(t, 60*time.Second, cfg.Cache.Expiration)
+	assert.Equal(t, 5*time.Second, cfg.Postgres.StatementTimeout)
+	assert.Equal(t, config.DevAccountID, cfg.Dev.AccountID)
+	assert.Equal(t, ":8080", cfg.Addr())
+
+	cfg.Dev.Enabled = true
+	assert.Equal(t, "127.0.0.1:8080", cfg.Addr(), "Expected dev mode to listen on loopback")
+	cfg.Host = "0.0.0.0"
+	assert.Equal(t, "0.0.0.0:8080", cfg.Addr())
+}
+
+func TestLoadEnv(t *testing.T) {
+	testRequired(t)
+	t.Setenv("PORT", "9090")
+	t.Setenv("REDIS_EXPIRE_LOW", "1500")
+	t.Setenv("CACHE_L1_EXPIRE", "2s")
+	t.Setenv("CACHE_L1_SIZE", "0")
+	t.Setenv("POSTGRES_SSL", "")
+	t.Setenv("IDP_CLIENT_SECRET", "")
+	t.Setenv("IDP_CLIENT_SECRET_FILE", testFile(t, "secret", "mounted\n"))
+	t.Setenv("REPLAY_ALLOWED_NETWORKS", "10.0.0.0/8, 192.168.1.0/24")
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "https://app.example.com")
+
+	cfg, err := config.Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, 1500*time.Millisecond, cfg.Cache.Expiration, "Expected milliseconds")
+	assert.Equal(t, 2*time.Second, cfg.Cache.L1Expiration)
+	assert.Equal(t, 0, cfg.Cache.L1Size)
+	assert.Equal(t, "mounted", cfg.IDP.ClientSecret, "Expected the secret file without newline")
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.0/24"}, cfg.Replay.AllowedNetworks)
+	assert.Equal(t, 2, len(cfg.Replay.Networks()))
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.Stream.AllowedOrigins)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	testRequired(t)
+	t.Setenv("CONFIG_FILE", testFile(t, "config.yaml", `
+port: "7070"
+log_level: info
+cache:
+  expiration: 30s
+redis:
+  key: hooks
+`))
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := config.Load(testFlags())
+	assert.Nil(t, err)
+	assert.Equal(t, "7070", cfg.Port, "Expected the file over the defaults")
+	assert.Equal(t, "warn", cfg.LogLevel, "Expected the env over the file")
+	assert.Equal(t, 30*time.Second, cfg.Cache.Expiration)
+	assert.Equal(t, "hooks", cfg.Redis.Key)
+
+	cfg, err = config.Load(testFlags("--log-level", "debug", "--dev"))
+	assert.Nil(t, err)
+	assert.Equal(t, "debug", cfg.LogLevel, "Expected the flag over the env")
+	assert.True(t, cfg.Dev.Enabled)
+
+	flags := testFlags()
+	for _, secret := range []string{"idp-client-secret", "postgres-pass", "redis-auth"} {
+		assert.Nil(t, flags.Lookup(secret), "Expected no flag for %s", secret)
+	}
+	assert.NotNil(t, flags.Lookup("postgres-host"))
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		expected []string
+	}{
+		"missing services": {
+			env:      map[string]string{},
+			expected: []string{"IDP_URI is required", "REDIS_HOST is required", "POSTGRES_HOST is required"},
+		},
+		"dev mode": {
+			env:      map[string]string{"DEV": "true", "PORT": "0"},
+			expected: []string{"PORT must be a port number"},
+		},
+		"invalid values": {
+			env:      map[string]string{"DEV": "true", "LOG_LEVEL": "loud", "LOG_FORMAT": "xml", "REDIS_EXPIRE_LOW": "-1"},
+			expected: []string{"LOG_LEVEL must be one of", "LOG_FORMAT must be text or json", "REDIS_EXPIRE_LOW must not be negative"},
+		},
+		"unknown driver": {
+			env:      map[string]string{"DB_DRIVER": "mysql"},
+			expected: []string{"DB_DRIVER must be postgres or sqlite"},
+		},
+		"unknown exporter": {
+			env:      map[string]string{"DEV": "true", "TRACING_EXPORTER": "jaeger"},
+			expected: []string{"TRACING_EXPORTER must be none, otlp or stdout"},
+		},
+		"invalid network": {
+			env:      map[string]string{"DEV": "true", "REPLAY_ALLOWED_NETWORKS": "10.0.0.0/8, localhost"},
+			expected: []string{"REPLAY_ALLOWED_NETWORKS must list CIDRs"},
+		},
+		"cache outliving versions": {
+			env:      map[string]string{"DEV": "true", "REDIS_EXPIRE_LOW": "24h"},
+			expected: []string{"REDIS_EXPIRE_LOW must be shorter than 24h"},
+		},
+		"invalid proxy": {
+			env:      map[string]string{"DEV": "true", "TRUSTED_PROXIES": "proxy.internal"},
+			expected: []string{"TRUSTED_PROXIES must list CIDRs"},
+		},
+		"invalid origin": {
+			env:      map[string]string{"DEV": "true", "STREAM_ALLOWED_ORIGINS": "app.example.com"},
+			expected: []string{"STREAM_ALLOWED_ORIGINS must list origins"},
+		},
+		"unparsable": {
+			env:      map[string]string{"CACHE_L1_SIZE": "many"},
+			expected: []string{"CACHE_L1_SIZE must be a number"},
+		},
+		"secret twice": {
+			env:      map[string]string{"REDIS_AUTH": "inline", "REDIS_AUTH_FILE": "/run/secrets/redis"},
+			expected: []string{"REDIS_AUTH and REDIS_AUTH_FILE are both set"},
+		},
+		"missing secret file": {
+			env:      map[string]string{"REDIS_AUTH_FILE": "/nonexistent/redis"},
+			expected: []string{"could not read REDIS_AUTH_FILE"},
+		},
+		"missing config file": {
+			env:      map[string]string{"CONFIG_FILE": "/nonexistent/config.yaml"},
+			expected: []string{"could not read configuration"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := config.Load(nil)
+			if !assert.NotNil(t, err) {
+				return
+			}
+			for _, expected := range test.expected {
+				assert.True(t, strings.Contains(err.Error(), expected), "Expected %q in %q", expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	t.Setenv("CONFIG_FILE", testFile(t, "config.yaml", "prot: 8080\n"))
+
+	_, err := config.Load(nil)
+	assert.NotNil(t, err, "Expected misspelled settings to be rejected")
+}
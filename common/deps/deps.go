@@ -1,36 +1,40 @@
 package deps
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/health"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres
-	"github.com/joho/godotenv"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"   // SQLite
 	"github.com/sirupsen/logrus"
 )
 
-// LoadEnv loads all env variables from .env file relative to the source of execution.
-// If no .env file is provided, .env will be loaded from deps.go directory.
-func LoadEnv(file ...string) {
-	err := godotenv.Load(file...)
-	if err != nil {
-		log.Fatalf("did not load from .env, %s , %s", file, err.Error())
-	}
-}
+const (
+	checkTimeout = 2 * time.Second
+)
 
-// ConfigureLog configures the logger
+// ConfigureLog configures the logger to write text or json lines
 // Returns true if it's in developer env, otherwise prod
-func ConfigureLog() bool {
+func ConfigureLog(cfg *config.Config) bool {
 	logrus.SetOutput(os.Stdout)
-	logrus.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	if cfg.LogFormat == "json" {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logrus.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
 
-	switch os.Getenv("LOG_LEVEL") {
+	switch cfg.LogLevel {
 	case "trace":
 		logrus.SetLevel(logrus.TraceLevel)
 		return true
@@ -49,52 +53,149 @@ func ConfigureLog() bool {
 	}
 }
 
-// Router creates the default gin router
-func Router() *gin.Engine {
+// Router creates the gin router. Requests are logged by the logging
+// middleware rather than by gin.
+func Router(cfg *config.Config) *gin.Engine {
 	gin.SetMode("release")
-	if ConfigureLog() {
+	if ConfigureLog(cfg) {
 		gin.SetMode("debug")
 	}
 
-	return gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery())
+	// the client IP is only read from the forwarded headers of trusted proxies
+	if err := router.SetTrustedProxies(cfg.Proxies.Trusted); err != nil {
+		logrus.Fatal(err)
+	}
+
+	return router
 }
 
 // Redis returns a redis connection
-func Redis() *redis.Client {
+func Redis(cfg *config.Config) *redis.Client {
 	options := &redis.Options{
-		Addr:     os.Getenv("REDIS_HOST"),
-		Password: "",
-	}
-
-	redisAuth := os.Getenv("REDIS_AUTH")
-	if redisAuth != "" {
-		options.Password = redisAuth
+		Addr:     cfg.Redis.Host,
+		Password: cfg.Redis.Auth,
 	}
 
 	redis := redis.NewClient(options)
+	cache.SetPrefix(cfg.Redis.Key)
 
 	logrus.Info("redis connection √")
 
 	return redis
 }
 
+// Cache returns the bin cache. Unless disabled, each instance keeps a short
+// lived copy of the redis entries, invalidated by the other instances until
+// ctx is done.
+func Cache(ctx context.Context, client *redis.Client, cfg *config.Config) cache.Cache {
+	shared := cache.NewRedis(client)
+	if cfg.Cache.L1Size <= 0 {
+		return shared
+	}
+
+	tiered := cache.NewTiered(cache.NewMemory(cfg.Cache.L1Size), shared, shared, cfg.Cache.L1Expiration)
+	if err := tiered.Listen(ctx); err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Info("cache invalidation √")
+
+	return tiered
+}
+
+// Database connects to the configured database, Postgres unless the driver
+// is sqlite
+func Database(cfg *config.Config) *gorm.DB {
+	return connected(OpenDatabase(cfg))
+}
+
+// OpenDatabase connects to the configured database like Database, but
+// returns the connection error instead of exiting
+func OpenDatabase(cfg *config.Config) (*gorm.DB, error) {
+	switch cfg.Database.Driver {
+	case "sqlite", "sqlite3":
+		return openSQLite(cfg)
+	default:
+		return openPostgres(cfg)
+	}
+}
+
+// SQLite opens the configured database file
+func SQLite(cfg *config.Config) *gorm.DB {
+	return connected(openSQLite(cfg))
+}
+
 // Postgres creates the new postgres connection
-func Postgres() *gorm.DB {
+func Postgres(cfg *config.Config) *gorm.DB {
+	return connected(openPostgres(cfg))
+}
+
+// Checks lists the dependencies the server needs to serve requests. They are
+// only checked with authentication enabled, outside of dev mode, where the
+// identity provider authenticates every request.
+func Checks(cfg *config.Config, database *gorm.DB, client *redis.Client) *health.Checker {
+	return checks(cfg, health.Database(database), health.Migrations(database), client)
+}
+
+// ChecksUnopened lists the same dependencies when the database could not be
+// opened, its checks fail with err
+func ChecksUnopened(cfg *config.Config, err error, client *redis.Client) *health.Checker {
+	return checks(cfg, health.Failing(err), health.Failing(err), client)
+}
+
+func checks(cfg *config.Config, database health.Check, migrations health.Check, client *redis.Client) *health.Checker {
+	checker := health.New(checkTimeout)
+	checker.Add("database", database)
+	checker.Add("migrations", migrations)
+	checker.Add("redis", health.Redis(client))
+	checker.Add("oidc", health.OIDC(cfg.IDP))
+
+	return checker
+}
+
+func openSQLite(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open("sqlite3", "file:"+cfg.Database.SQLitePath+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, concurrent transactions would fail
+	// with a locked database instead of waiting for each other
+	db.DB().SetMaxOpenConns(1)
+
+	if cfg.Debug() {
+		db.LogMode(true)
+	}
+
+	db.SingularTable(true)
+
+	logrus.Info("sqlite connection √")
+
+	return db, nil
+}
+
+func openPostgres(cfg *config.Config) (*gorm.DB, error) {
 	pg := []string{
-		"host=" + os.Getenv("POSTGRES_HOST"),
-		"port=" + os.Getenv("POSTGRES_PORT"),
-		"user=" + os.Getenv("POSTGRES_USER"),
-		"dbname=" + os.Getenv("POSTGRES_DB"),
-		"password=" + os.Getenv("POSTGRES_PASS"),
-		"sslmode=" + os.Getenv("POSTGRES_SSL"),
+		"host=" + cfg.Postgres.Host,
+		"port=" + cfg.Postgres.Port,
+		"user=" + cfg.Postgres.User,
+		"dbname=" + cfg.Postgres.DB,
+		"password=" + cfg.Postgres.Pass,
+		"sslmode=" + cfg.Postgres.SSL,
+	}
+	if timeout := cfg.Postgres.StatementTimeout; timeout > 0 {
+		// bounds the statements of the repositories that set no timeout
+		pg = append(pg, fmt.Sprintf("statement_timeout=%d", timeout.Milliseconds()))
 	}
 
 	db, err := gorm.Open("postgres", strings.Join(pg, " "))
 	if err != nil {
-		logrus.Fatal(err)
+		return nil, err
 	}
 
-	if level := os.Getenv("LOG_LEVEL"); level == "debug" || level == "trace" {
+	if cfg.Debug() {
 		db.LogMode(true)
 	}
 
@@ -102,5 +203,12 @@ func Postgres() *gorm.DB {
 
 	logrus.Info("postgres connection √")
 
+	return db, nil
+}
+
+func connected(db *gorm.DB, err error) *gorm.DB {
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	return db
 }
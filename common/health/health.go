@@ -0,0 +1,187 @@
+// Package health checks the dependencies of the server
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/migrations"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// StatusOK reports a passing check, or a report where every check passed
+	StatusOK = "ok"
+	// StatusFailing reports a failing check, or a report where one failed
+	StatusFailing = "failing"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check returns an error when the dependency cannot serve requests
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check
+type Result struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check, in the order they were added
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks"`
+}
+
+// Checker runs the checks concurrently, each bounded by Timeout
+type Checker struct {
+	Timeout time.Duration
+
+	names  []string
+	checks []Check
+}
+
+// New ...
+func New(timeout time.Duration) *Checker {
+	return &Checker{Timeout: timeout}
+}
+
+// Add registers a check under name
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Run runs every check until ctx is done
+func (c *Checker) Run(ctx context.Context) *Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	report := &Report{Status: StatusOK, Checks: make([]*Result, len(c.checks))}
+
+	var wg sync.WaitGroup
+	for i := range c.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := run(ctx, c.checks[i])
+			result := &Result{
+				Name:    c.names[i],
+				Status:  StatusOK,
+				Latency: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFailing
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	return report
+}
+
+// run returns once ctx is done even if the check ignores ctx, as gorm v1 does
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Failing always fails with err, for a dependency that could not be opened
+func Failing(err error) Check {
+	return func(ctx context.Context) error {
+		return err
+	}
+}
+
+// Database pings the database
+func Database(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return errors.New("not connected")
+		}
+		return db.DB().PingContext(ctx)
+	}
+}
+
+// Migrations fails while some migrations are not applied
+func Migrations(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return errors.New("not connected")
+		}
+
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
+
+// Redis pings redis
+func Redis(client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		return cache.Process(ctx, client, time.Until(deadline), redis.NewStatusCmd("ping"))
+	}
+}
+
+// OIDC fetches the discovery document of the identity provider
+func OIDC(idp config.IDP) Check {
+	discovery := idp.URI + "/realms/" + idp.Realm + "/.well-known/openid-configuration"
+
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("discovery answered %s", resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/common/health"
+	"github.com/hugocortes/hooks-api/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	checker := health.New(50 * time.Millisecond)
+	checker.Add("passing", func(ctx context.Context) error { return nil })
+	checker.Add("failing", func(ctx context.Context) error { return errors.New("refused") })
+	checker.Add("stuck", func(ctx context.Context) error {
+		// ignores ctx, as gorm v1 does
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "Expected the stuck check to time out")
+
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, 3, len(report.Checks))
+	assert.Equal(t, "passing", report.Checks[0].Name)
+	assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+	assert.Equal(t, "refused", report.Checks[1].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error)
+	assert.True(t, report.Checks[2].Latency >= 50)
+
+	report = health.New(0).Run(context.Background())
+	assert.Equal(t, health.StatusOK, report.Status)
+}
+
+func TestMigrations(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "hooks.db")
+	db := deps.SQLite(cfg)
+	defer db.Close()
+
+	ctx := context.Background()
+	assert.Nil(t, health.Database(db)(ctx))
+	assert.NotNil(t, health.Migrations(db)(ctx), "Expected a database without migrations to fail")
+
+	migrations.Run(db)
+	assert.Nil(t, health.Migrations(db)(ctx))
+
+	db.Exec("DELETE FROM migrations WHERE id = ?", "202610180007")
+	err := health.Migrations(db)(ctx)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "202610180007"))
+	}
+
+	assert.NotNil(t, health.Database(nil)(ctx))
+
+	refused := errors.New("connection refused")
+	assert.Equal(t, refused, health.Failing(refused)(ctx))
+}
+
+func TestOIDC(t *testing.T) {
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/hooks/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"issuer":"hooks"}`))
+	}))
+	defer idp.Close()
+
+	ctx := context.Background()
+	assert.Nil(t, health.OIDC(config.IDP{URI: idp.URL, Realm: "hooks"})(ctx))
+
+	err := health.OIDC(config.IDP{URI: idp.URL, Realm: "other"})(ctx)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "404"))
+	}
+}
@@ -0,0 +1,101 @@
+// Package logging carries a structured logger through the context of every
+// request
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader carries the ID of the request, generated unless the
+	// client sent one
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestID = 128
+)
+
+type loggerContextKey struct{}
+
+// FromContext returns the logger of the request, the standard logger outside
+// of a request. The ID of the trace in ctx is logged too.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry, ok := ctx.Value(loggerContextKey{}).(*logrus.Entry)
+	if !ok {
+		entry = logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		if _, ok := entry.Data["trace_id"]; !ok {
+			entry = entry.WithField("trace_id", span.TraceID().String())
+		}
+	}
+
+	return entry
+}
+
+// With returns ctx, its logger carrying the field
+func With(ctx context.Context, key string, value interface{}) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, FromContext(ctx).WithField(key, value))
+}
+
+// Set adds the field to the logger of the request
+func Set(c *gin.Context, key string, value interface{}) {
+	c.Request = c.Request.WithContext(With(c.Request.Context(), key, value))
+}
+
+// Param adds the route param to the logger of the request under key
+func Param(key string, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value := c.Param(param); value != "" {
+			Set(c, key, value)
+		}
+		c.Next()
+	}
+}
+
+// Middleware identifies every request by the X-Request-ID it was sent with,
+// or a new one, and logs the request once answered. It must run after the
+// tracing middleware for the trace ID to be logged.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !valid(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, requestID)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", requestID))
+		Set(c, "request_id", requestID)
+
+		start := time.Now()
+		c.Next()
+
+		// handlers down the chain may have added fields to the request
+		FromContext(c.Request.Context()).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"status":     c.Writer.Status(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}).Info("request")
+	}
+}
+
+// valid accepts the printable request IDs of a reasonable length, so that
+// clients cannot forge log lines
+func valid(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestID {
+		return false
+	}
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package logging_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(logging.Middleware())
+	router.GET("/b/:binID", logging.Param("bin_id", "binID"), func(c *gin.Context) {
+		logging.Set(c, "account_id", "account")
+		logging.FromContext(c.Request.Context()).Warn("captured")
+		c.Status(http.StatusAccepted)
+	})
+	return router
+}
+
+func TestRequestID(t *testing.T) {
+	hook := test.NewGlobal()
+	router := testRouter()
+
+	tests := map[string]struct {
+		header   string
+		accepted bool
+	}{
+		"missing":   {header: "", accepted: false},
+		"sent":      {header: "4bf92f35-77b3", accepted: true},
+		"forged":    {header: "id\nlevel=error", accepted: false},
+		"too long":  {header: strings.Repeat("a", 129), accepted: false},
+		"non ascii": {header: "réquest", accepted: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hook.Reset()
+			req := httptest.NewRequest("GET", "/b/first", nil)
+			req.Header.Set(logging.RequestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(logging.RequestIDHeader)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, tt.accepted, requestID == tt.header)
+
+			entries := hook.AllEntries()
+			if !assert.Equal(t, 2, len(entries)) {
+				return
+			}
+			assert.Equal(t, requestID, entries[0].Data["request_id"])
+			assert.Equal(t, "first", entries[0].Data["bin_id"])
+			assert.Equal(t, "account", entries[0].Data["account_id"])
+
+			assert.Equal(t, "request", entries[1].Message)
+			assert.Equal(t, requestID, entries[1].Data["request_id"])
+			assert.Equal(t, "account", entries[1].Data["account_id"], "Expected the fields added down the chain")
+			assert.Equal(t, http.StatusAccepted, entries[1].Data["status"])
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	entry := logging.FromContext(context.Background())
+	assert.Equal(t, logrus.StandardLogger(), entry.Logger)
+	assert.Empty(t, entry.Data)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	ctx = logging.With(ctx, "bin_id", "first")
+	entry = logging.FromContext(ctx)
+	assert.Equal(t, "first", entry.Data["bin_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry.Data["trace_id"])
+}
@@ -0,0 +1,193 @@
+// Package metrics collects the prometheus metrics of the server
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The results of a bin cache lookup
+const (
+	CacheHit       = "hit"
+	CacheMiss      = "miss"
+	CacheTombstone = "tombstone"
+)
+
+const (
+	namespace = "hooks"
+	// otherBin labels the captures of the bins past MaxBins
+	otherBin = "other"
+	// unmatched labels the requests that matched no route, so that scanned
+	// paths do not each become a series
+	unmatched = "unmatched"
+)
+
+// Metrics collects the metrics of every layer. A nil *Metrics records
+// nothing, so that metrics can be disabled by leaving it out.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests  *prometheus.HistogramVec
+	db        *prometheus.HistogramVec
+	dbErrors  *prometheus.CounterVec
+	cache     *prometheus.CounterVec
+	captures  *prometheus.CounterVec
+	databases sync.Once
+
+	maxBins int
+	mu      sync.Mutex
+	bins    map[string]struct{}
+}
+
+// New registers the metrics, or returns nil when they are disabled
+func New(cfg config.Metrics) *Metrics {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the http requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		db: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "bins_db",
+			Name:      "duration_seconds",
+			Help:      "Duration of the bin storage calls by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bins_db",
+			Name:      "errors_total",
+			Help:      "Failed bin storage calls by method and kind of error.",
+		}, []string{"method", "kind"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bins_cache",
+			Name:      "lookups_total",
+			Help:      "Bin cache lookups by repository method and result: hit, miss or tombstone.",
+		}, []string{"method", "result"}),
+		captures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "captures_total",
+			Help:      "Captured requests by bin.",
+		}, []string{"bin"}),
+		maxBins: cfg.MaxBins,
+		bins:    map[string]struct{}{},
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.db,
+		m.dbErrors,
+		m.cache,
+		m.captures,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware observes the duration of every request. Requests are labelled
+// with their route rather than their path, which holds the bin IDs.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatched
+		}
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Database exports the connection pool stats of db. Only the first database
+// is exported.
+func (m *Metrics) Database(name string, db *sql.DB) {
+	if m == nil || db == nil {
+		return
+	}
+
+	m.databases.Do(func() {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+	})
+}
+
+// DB observes a call to the bin storage that started at start. kind is the
+// kind of error it failed with, empty when it succeeded.
+func (m *Metrics) DB(method string, start time.Time, kind string) {
+	if m == nil {
+		return
+	}
+
+	m.db.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if kind != "" {
+		m.dbErrors.WithLabelValues(method, kind).Inc()
+	}
+}
+
+// Cache counts a bin cache lookup of the repository method by its result
+func (m *Metrics) Cache(method string, result string) {
+	if m == nil {
+		return
+	}
+
+	m.cache.WithLabelValues(method, result).Inc()
+}
+
+// Capture counts a request captured by the bin
+func (m *Metrics) Capture(binID string) {
+	if m == nil {
+		return
+	}
+
+	m.captures.WithLabelValues(m.bin(binID)).Inc()
+}
+
+// bin returns the label of the bin, other once MaxBins bins were labelled
+func (m *Metrics) bin(binID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.bins[binID]; ok {
+		return binID
+	}
+	if len(m.bins) >= m.maxBins {
+		return otherBin
+	}
+
+	m.bins[binID] = struct{}{}
+	return binID
+}
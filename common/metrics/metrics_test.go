@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func testScrape(t *testing.T, m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New(config.Default().Metrics)
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/b/:binID", func(c *gin.Context) { c.Status(http.StatusAccepted) })
+
+	for _, path := range []string{"/b/first", "/b/second", "/wp-admin"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	scraped := testScrape(t, m)
+	assert.Contains(t, scraped, `hooks_http_request_duration_seconds_count{method="GET",route="/b/:binID",status="202"} 2`)
+	assert.Contains(t, scraped, `hooks_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+	assert.NotContains(t, scraped, "first", "Expected the route instead of the path")
+}
+
+func TestCaptureCardinality(t *testing.T) {
+	m := metrics.New(config.Metrics{Enabled: true, MaxBins: 2})
+	for _, binID := range []string{"first", "second", "first", "third", "fourth"} {
+		m.Capture(binID)
+	}
+
+	scraped := testScrape(t, m)
+	assert.Contains(t, scraped, `hooks_captures_total{bin="first"} 2`)
+	assert.Contains(t, scraped, `hooks_captures_total{bin="second"} 1`)
+	assert.Contains(t, scraped, `hooks_captures_total{bin="other"} 2`)
+
+	m = metrics.New(config.Metrics{Enabled: true})
+	m.Capture("first")
+	scraped = testScrape(t, m)
+	assert.Contains(t, scraped, `hooks_captures_total{bin="other"} 1`, "Expected bin IDs to be opted out")
+	assert.NotContains(t, scraped, "first")
+}
+
+func TestDisabled(t *testing.T) {
+	m := metrics.New(config.Metrics{})
+	assert.Nil(t, m)
+
+	// every recorder accepts a nil *Metrics
+	m.Capture("first")
+	m.Cache("Get", metrics.CacheHit)
+	m.DB("Get", time.Now(), "not_found")
+	m.Database("sqlite", nil)
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
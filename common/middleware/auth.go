@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/problem"
+)
+
+const (
+	accountIDKey = "accountID"
+	// tokenParam carries the bearer token of the streams opened by browsers
+	tokenParam = "access_token"
+)
+
+type accountIDContextKey struct{}
+
+// Authenticate verifies the bearer token of every request with the identity
+// provider. The account is read from the configured claim or the token subject.
+// It fails when the identity provider cannot be discovered.
+func (h *Middleware) Authenticate() (gin.HandlerFunc, error) {
+	config, err := h.oAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return Bearer(&config.verifier, h.idp.AccountClaim), nil
+}
+
+// Bearer validates the Authorization bearer token with the given verifier and
+// stores the account found in claim on the request context
+func Bearer(verifier *oidc.IDTokenVerifier, claim string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			unauthorized(c, "Missing bearer token")
+			return
+		}
+
+		idToken, err := verifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			unauthorized(c, "Invalid bearer token")
+			return
+		}
+
+		accountID := idToken.Subject
+		if claim != "" {
+			claims := map[string]interface{}{}
+			if err := idToken.Claims(&claims); err != nil {
+				unauthorized(c, "Invalid bearer token")
+				return
+			}
+			accountID, _ = claims[claim].(string)
+		}
+		if accountID == "" {
+			unauthorized(c, "Token does not identify an account")
+			return
+		}
+
+		SetAccountID(c, accountID)
+		c.Next()
+	}
+}
+
+// bearerToken returns the token of the Authorization header. Browsers cannot
+// set headers on EventSource and WebSocket, so streams may send the token in
+// the access_token query parameter instead. The access tokens of the identity
+// provider are short lived and the logs only record the path.
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) >= 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+
+	if streaming(c.Request) {
+		return c.Query(tokenParam)
+	}
+	return ""
+}
+
+// streaming tells whether the request opens a websocket or an event stream
+func streaming(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// Static authenticates every request as the given account. It replaces Bearer
+// in dev mode, where no identity provider is available.
+func Static(accountID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		SetAccountID(c, accountID)
+		c.Next()
+	}
+}
+
+// SetAccountID stores the authenticated account on the gin and request
+// context, and on the logger of the request
+func SetAccountID(c *gin.Context, accountID string) {
+	c.Set(accountIDKey, accountID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), accountIDContextKey{}, accountID))
+	logging.Set(c, "account_id", accountID)
+}
+
+// AccountID returns the authenticated account of the request
+func AccountID(c *gin.Context) string {
+	return c.GetString(accountIDKey)
+}
+
+// AccountIDFromContext returns the authenticated account stored by Bearer
+func AccountIDFromContext(ctx context.Context) string {
+	accountID, _ := ctx.Value(accountIDContextKey{}).(string)
+	return accountID
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", "Bearer")
+	problem.Abort(c, http.StatusUnauthorized, message)
+}
@@ -0,0 +1,155 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/common/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIssuer   = "https://idp.test/realms/hooks"
+	testClientID = "hooks-api"
+)
+
+// unsignedKeySet trusts the payload of any token, signatures are not checked
+type unsignedKeySet struct{}
+
+func (unsignedKeySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed jwt")
+	}
+	return base64.RawURLEncoding.DecodeString(parts[1])
+}
+
+func testToken(claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString([]byte("signature"))
+}
+
+func testClaims(subject string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss": testIssuer,
+		"aud": testClientID,
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func testAuthRouter(claim string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	verifier := oidc.NewVerifier(testIssuer, unsignedKeySet{}, &oidc.Config{ClientID: testClientID})
+
+	router := gin.New()
+	router.GET("/", middleware.Bearer(verifier, claim), func(c *gin.Context) {
+		if middleware.AccountID(c) != middleware.AccountIDFromContext(c.Request.Context()) {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, middleware.AccountID(c))
+	})
+	return router
+}
+
+func testAuthRequest(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBearerSubject(t *testing.T) {
+	router := testAuthRouter("")
+	accountID := uuid.New().String()
+
+	w := testAuthRequest(router, "Bearer "+testToken(testClaims(accountID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, accountID, w.Body.String())
+}
+
+func TestBearerClaim(t *testing.T) {
+	router := testAuthRouter("account_id")
+	accountID := uuid.New().String()
+
+	claims := testClaims(uuid.New().String())
+	claims["account_id"] = accountID
+	w := testAuthRequest(router, "Bearer "+testToken(claims))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, accountID, w.Body.String())
+
+	w = testAuthRequest(router, "Bearer "+testToken(testClaims(uuid.New().String())))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Missing claim should be rejected")
+}
+
+func TestBearerQuery(t *testing.T) {
+	router := testAuthRouter("")
+	accountID := uuid.New().String()
+	path := "/?access_token=" + testToken(testClaims(accountID))
+
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, accountID, w.Body.String())
+
+	req = httptest.NewRequest("GET", path, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected the query token to be refused outside streams")
+}
+
+func TestBearerRejected(t *testing.T) {
+	router := testAuthRouter("")
+
+	expired := testClaims(uuid.New().String())
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	otherAudience := testClaims(uuid.New().String())
+	otherAudience["aud"] = "other-client"
+
+	tests := map[string]string{
+		"missing header":  "",
+		"basic auth":      "Basic dXNlcjpwYXNz",
+		"malformed token": "Bearer not-a-token",
+		"expired token":   "Bearer " + testToken(expired),
+		"other audience":  "Bearer " + testToken(otherAudience),
+		"missing subject": "Bearer " + testToken(testClaims("")),
+	}
+
+	for name, authorization := range tests {
+		w := testAuthRequest(router, authorization)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"), name)
+	}
+}
+
+func TestStatic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accountID := uuid.New().String()
+
+	router := gin.New()
+	router.GET("/", middleware.Static(accountID), func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.AccountIDFromContext(c.Request.Context()))
+	})
+
+	w := testAuthRequest(router, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, accountID, w.Body.String())
+}
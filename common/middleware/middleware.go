@@ -4,10 +4,10 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"reflect"
 	"strings"
 	"time"
@@ -15,13 +15,24 @@ import (
 	oidc "github.com/coreos/go-oidc"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/problem"
+	"github.com/hugocortes/hooks-api/common/tracing"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 )
 
+const (
+	idpTimeout = 10 * time.Second
+)
+
 // Middleware provides http middleware
 type Middleware struct {
 	gin *gin.Engine
+	idp config.IDP
+	// client calls the identity provider, traced
+	client *http.Client
 }
 
 type oauthConfig struct {
@@ -30,13 +41,13 @@ type oauthConfig struct {
 }
 
 // New provides middleware funcs
-func New(gin *gin.Engine) *Middleware {
-	return &Middleware{gin: gin}
+func New(gin *gin.Engine, idp config.IDP) *Middleware {
+	return &Middleware{gin: gin, idp: idp, client: tracing.Client(idpTimeout)}
 }
 
 // NotFound provides 404 route handling
 func (h *Middleware) NotFound(c *gin.Context) {
-	c.JSON(404, gin.H{"status": 404, "error": "Not Found", "message": "Not Found"})
+	problem.Abort(c, http.StatusNotFound, "Not Found")
 }
 
 // CorsConfig provides cors
@@ -51,28 +62,22 @@ func (h *Middleware) CorsConfig() gin.HandlerFunc {
 	})
 }
 
-func (h *Middleware) oAuthConfig() oauthConfig {
-	realm := os.Getenv("IDP_REALM")
-	clientID := os.Getenv("IDP_CLIENT_ID")
-	clientSecret := os.Getenv("IDP_CLIENT_SECRET")
-	if clientID == "" || clientSecret == "" || realm == "" {
-		logrus.Fatal("Missing configuration")
-	}
-
-	configURL := os.Getenv("IDP_URI") + "/realms/" + realm
-	ctx := context.Background()
+// oAuthConfig discovers the endpoints and keys of the identity provider
+func (h *Middleware) oAuthConfig() (oauthConfig, error) {
+	configURL := h.idp.URI + "/realms/" + h.idp.Realm
+	ctx := oidc.ClientContext(context.Background(), h.client)
 	provider, err := oidc.NewProvider(ctx, configURL)
 	if err != nil {
-		panic(err)
+		return oauthConfig{}, fmt.Errorf("identity provider discovery failed: %w", err)
 	}
 
 	oidcConfig := &oidc.Config{
-		ClientID: clientID,
+		ClientID: h.idp.ClientID,
 	}
 
 	oauth2Config := oauth2.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
+		ClientID:     h.idp.ClientID,
+		ClientSecret: h.idp.ClientSecret,
 		Endpoint:     provider.Endpoint(),
 		Scopes:       []string{oidc.ScopeOpenID},
 	}
@@ -81,7 +86,7 @@ func (h *Middleware) oAuthConfig() oauthConfig {
 	return oauthConfig{
 		verifier: *provider.Verifier(oidcConfig),
 		oauth2:   oauth2Config,
-	}
+	}, nil
 }
 
 // Auth provides the authentication callback and redirect URL required for
@@ -91,10 +96,14 @@ func (h *Middleware) Auth() {
 		redirectURI := c.Query("redirect_uri")
 		state := c.Query("state")
 		if redirectURI == "" || state == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Missing required query params"})
+			problem.Abort(c, http.StatusBadRequest, "Missing required query params")
+			return
+		}
+		config, err := h.oAuthConfig()
+		if err != nil {
+			unavailable(c, err)
 			return
 		}
-		config := h.oAuthConfig()
 		config.oauth2.RedirectURL = redirectURI
 
 		c.Redirect(http.StatusMovedPermanently, config.oauth2.AuthCodeURL(state))
@@ -108,25 +117,30 @@ func (h *Middleware) Auth() {
 			redirectURI := c.PostForm("redirect_uri")
 			code := c.PostForm("code")
 			if redirectURI == "" || code == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"message": "Missing required form params"})
+				problem.Abort(c, http.StatusBadRequest, "Missing required form params")
 				return
 			}
 
-			config := h.oAuthConfig()
+			config, err := h.oAuthConfig()
+			if err != nil {
+				unavailable(c, err)
+				return
+			}
 			config.oauth2.RedirectURL = redirectURI
-			oauthToken, err := config.oauth2.Exchange(c, code)
+			ctx := context.WithValue(c.Request.Context(), oauth2.HTTPClient, h.client)
+			oauthToken, err := config.oauth2.Exchange(ctx, code)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch token"})
+				problem.Abort(c, http.StatusInternalServerError, "Failed to fetch token")
 				return
 			}
 			rawIDToken, ok := oauthToken.Extra("id_token").(string)
 			if !ok {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "No id_token field in oauth2 token"})
+				problem.Abort(c, http.StatusInternalServerError, "No id_token field in oauth2 token")
 				return
 			}
 			_, err = config.verifier.Verify(c, rawIDToken)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to verify token" + err.Error()})
+				problem.Abort(c, http.StatusBadRequest, "Failed to verify token: "+err.Error())
 				return
 			}
 
@@ -135,11 +149,15 @@ func (h *Middleware) Auth() {
 		case "refresh_token":
 			refreshToken := c.PostForm("refresh_token")
 			if refreshToken == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"message": "Missing refresh token"})
+				problem.Abort(c, http.StatusBadRequest, "Missing refresh token")
 				return
 			}
 
-			config := h.oAuthConfig()
+			config, err := h.oAuthConfig()
+			if err != nil {
+				unavailable(c, err)
+				return
+			}
 			form := url.Values{}
 			form.Add("refresh_token", refreshToken)
 			form.Add("grant_type", "refresh_token")
@@ -147,22 +165,21 @@ func (h *Middleware) Auth() {
 			form.Add("client_id", config.oauth2.ClientID)
 			form.Add("client_secret", config.oauth2.ClientSecret)
 
-			client := &http.Client{Timeout: 10 * time.Second}
-			req, err := http.NewRequest("POST", config.oauth2.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+			req, err := http.NewRequestWithContext(c.Request.Context(), "POST", config.oauth2.Endpoint.TokenURL, strings.NewReader(form.Encode()))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal error"})
+				problem.Abort(c, http.StatusInternalServerError, "Internal error")
 				return
 			}
-			resp, err := client.Do(req)
+			resp, err := h.client.Do(req)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal error"})
+				problem.Abort(c, http.StatusInternalServerError, "Internal error")
 				return
 			}
 			defer resp.Body.Close()
 
 			if resp.StatusCode >= 400 {
-				c.JSON(resp.StatusCode, gin.H{"message": "Error"})
+				problem.Abort(c, resp.StatusCode, "Error")
 				return
 			}
 
@@ -201,8 +218,15 @@ func (h *Middleware) Auth() {
 			c.JSON(resp.StatusCode, test)
 			return
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown grant type"})
+			problem.Abort(c, http.StatusBadRequest, "Unknown grant type")
 			return
 		}
 	})
 }
+
+// unavailable answers the requests that need an identity provider which
+// cannot be reached
+func unavailable(c *gin.Context, err error) {
+	logging.FromContext(c.Request.Context()).Error(err)
+	problem.Abort(c, http.StatusServiceUnavailable, "Identity provider unavailable")
+}
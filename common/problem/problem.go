@@ -0,0 +1,91 @@
+// Package problem writes RFC 7807 problem details responses
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/logging"
+)
+
+const (
+	// ContentType of every problem response
+	ContentType = "application/problem+json"
+)
+
+// Problem is the RFC 7807 problem details object
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// New returns a problem without a specific type
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+type kind struct {
+	err    error
+	status int
+}
+
+// kinds are registered by init functions and only read afterwards
+var kinds []kind
+
+// Register maps the errors matching err to the status. The message of err is
+// used as the detail of their problems. It must be called from an init
+// function.
+func Register(err error, status int) {
+	for i := range kinds {
+		if kinds[i].err == err {
+			kinds[i].status = status
+			return
+		}
+	}
+	kinds = append(kinds, kind{err: err, status: status})
+}
+
+// From returns the problem of err. Unregistered errors are internal errors
+// whose message is not disclosed.
+func From(err error) *Problem {
+	for _, kind := range kinds {
+		if errors.Is(err, kind.err) {
+			return New(kind.status, kind.err.Error())
+		}
+	}
+	return New(http.StatusInternalServerError, "Internal error")
+}
+
+// Write aborts the request with the problem
+func Write(c *gin.Context, problem *Problem) {
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// Abort aborts the request with a problem of the status
+func Abort(c *gin.Context, status int, detail string) {
+	Write(c, New(status, detail))
+}
+
+// Error aborts the request with the problem of err. Server errors are logged.
+func Error(c *gin.Context, err error) {
+	problem := From(err)
+	if problem.Status >= http.StatusInternalServerError {
+		logging.FromContext(c.Request.Context()).Error(err)
+	}
+
+	Write(c, problem)
+}
@@ -0,0 +1,58 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/problem"
+	"github.com/stretchr/testify/assert"
+)
+
+var errGone = errors.New("thing is gone")
+
+func init() {
+	problem.Register(errGone, http.StatusGone)
+}
+
+func TestError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+	}{
+		{"registered", errGone, http.StatusGone, "thing is gone"},
+		{"wrapped", fmt.Errorf("lookup: %w", errGone), http.StatusGone, "thing is gone"},
+		{"unregistered", errors.New("dial tcp: connection refused"), http.StatusInternalServerError, "Internal error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/things/1", nil)
+
+			problem.Error(c, test.err)
+			assert.True(t, c.IsAborted())
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+			body := &problem.Problem{}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), body))
+			assert.Equal(t, &problem.Problem{
+				Type:     "about:blank",
+				Title:    http.StatusText(test.status),
+				Status:   test.status,
+				Detail:   test.detail,
+				Instance: "/things/1",
+			}, body)
+		})
+	}
+}
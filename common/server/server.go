@@ -0,0 +1,218 @@
+// Package server runs the http server until it is shut down
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/health"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	statusStopping    = "shutting down"
+)
+
+// Server serves http until a signal arrives, then shuts down in order:
+// readiness fails, the stop hooks end the streams and background workers,
+// the requests in flight are drained and the close hooks release the
+// connections.
+type Server struct {
+	HTTP    *http.Server
+	Checks  *health.Checker
+	Delay   time.Duration
+	Timeout time.Duration
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping int32
+
+	mu      sync.Mutex
+	stops   []func()
+	closers []closer
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+// New ...
+func New(handler http.Handler, cfg *config.Config) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Server{
+		HTTP: &http.Server{
+			Addr:              cfg.Addr(),
+			Handler:           handler,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		Delay:   cfg.Shutdown.Delay,
+		Timeout: cfg.Shutdown.Timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Context is done once the requests drained, before the dependencies close.
+// Background workers stop with it.
+func (s *Server) Context() context.Context {
+	return s.ctx
+}
+
+// OnStop runs stop when the shutdown starts, before the requests are drained
+func (s *Server) OnStop(stop func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stops = append(s.stops, stop)
+}
+
+// OnClose runs close once the requests are drained. The closers run in the
+// reverse order of their registration.
+func (s *Server) OnClose(name string, close func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closers = append(s.closers, closer{name: name, close: close})
+}
+
+// Live answers as long as the server runs. The dependencies are left to
+// Ready, restarting would not bring them back.
+func (s *Server) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, &health.Report{Status: health.StatusOK, Checks: []*health.Result{}})
+}
+
+// Ready fails while a dependency fails, and once the shutdown starts
+func (s *Server) Ready(c *gin.Context) {
+	if s.Stopping() {
+		c.JSON(http.StatusServiceUnavailable, &health.Report{Status: statusStopping, Checks: []*health.Result{}})
+		return
+	}
+
+	report := s.check(c.Request.Context())
+	if report.Status != health.StatusOK {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (s *Server) check(ctx context.Context) *health.Report {
+	if s.Checks == nil {
+		return &health.Report{Status: health.StatusOK, Checks: []*health.Result{}}
+	}
+	return s.Checks.Run(ctx)
+}
+
+// Stopping reports whether the shutdown started
+func (s *Server) Stopping() bool {
+	return atomic.LoadInt32(&s.stopping) == 1
+}
+
+// Run serves on the configured address until SIGINT or SIGTERM. A second
+// signal kills the process without waiting for the drain.
+func (s *Server) Run() error {
+	listener, err := net.Listen("tcp", s.HTTP.Addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	logrus.Info("listening on ", listener.Addr())
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on the listener until ctx is done, then shuts down
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- s.HTTP.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		// the server failed on its own, there is nothing left to drain
+		s.stop()
+		s.close()
+		return err
+	case <-ctx.Done():
+		return s.Shutdown()
+	}
+}
+
+// Shutdown drains the requests in flight for up to Timeout, then closes the
+// remaining connections. Reaching the deadline is part of a normal shutdown
+// and only logged.
+func (s *Server) Shutdown() error {
+	logrus.Info("shutting down")
+	s.stop()
+
+	if s.Delay > 0 {
+		time.Sleep(s.Delay)
+	}
+
+	drain, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	err := s.HTTP.Shutdown(drain)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logrus.Warn("drain deadline exceeded, closing the remaining connections")
+		s.HTTP.Close()
+		err = nil
+	}
+
+	s.close()
+	logrus.Info("shutdown complete")
+
+	return err
+}
+
+func (s *Server) stop() {
+	if !atomic.CompareAndSwapInt32(&s.stopping, 0, 1) {
+		return
+	}
+
+	s.mu.Lock()
+	stops := s.stops
+	s.mu.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// close stops the background workers once no request needs them, then closes
+// the dependencies in the reverse order of their registration
+func (s *Server) close() {
+	s.cancel()
+
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(); err != nil {
+			logrus.Error("could not close ", closers[i].name, ": ", err)
+		}
+	}
+}
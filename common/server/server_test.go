@@ -0,0 +1,143 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/health"
+	"github.com/hugocortes/hooks-api/common/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func testReady(srv *server.Server) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/readyz", nil)
+	srv.Ready(c)
+	return w.Code
+}
+
+func TestShutdownDrains(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	started := make(chan struct{}, 1)
+	router := gin.New()
+	router.GET("/slow", func(c *gin.Context) {
+		started <- struct{}{}
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "captured")
+	})
+
+	cfg := config.Default()
+	srv := server.New(router, cfg)
+
+	var order []string
+	srv.OnStop(func() { order = append(order, "stop") })
+	srv.OnClose("first", func() error { order = append(order, "first"); return nil })
+	srv.OnClose("second", func() error { order = append(order, "second"); return errors.New("already closed") })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+	assert.Equal(t, http.StatusOK, testReady(srv))
+
+	response := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		assert.Nil(t, err)
+		response <- resp
+	}()
+	<-started
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		return testReady(srv) == http.StatusServiceUnavailable
+	}, time.Second, 5*time.Millisecond, "Expected readiness to flip")
+	assert.Nil(t, srv.Context().Err(), "Expected the background context to outlive the drain")
+
+	resp := <-response
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the request in flight to complete")
+		resp.Body.Close()
+	}
+
+	assert.Nil(t, <-served)
+	assert.NotNil(t, srv.Context().Err())
+	assert.Equal(t, []string{"stop", "second", "first"}, order)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	started := make(chan struct{}, 1)
+	router := gin.New()
+	router.GET("/stuck", func(c *gin.Context) {
+		started <- struct{}{}
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	cfg := config.Default()
+	cfg.Shutdown.Timeout = 50 * time.Millisecond
+	srv := server.New(router, cfg)
+
+	closed := false
+	srv.OnClose("database", func() error { closed = true; return nil })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	<-started
+
+	cancel()
+	select {
+	case err := <-served:
+		assert.Nil(t, err, "Expected the drain deadline not to fail the shutdown")
+	case <-time.After(time.Second):
+		t.Fatal("Expected the drain to give up at its deadline")
+	}
+	assert.True(t, closed, "Expected the connections to be closed after the deadline")
+}
+
+func TestReadyChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := server.New(gin.New(), config.Default())
+	assert.Equal(t, http.StatusOK, testReady(srv), "Expected no checks to be ready")
+
+	failing := errors.New("refused")
+	srv.Checks = health.New(time.Second)
+	srv.Checks.Add("redis", func(ctx context.Context) error { return failing })
+	assert.Equal(t, http.StatusServiceUnavailable, testReady(srv))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/healthz", nil)
+	srv.Live(c)
+	assert.Equal(t, http.StatusOK, w.Code, "Expected liveness to ignore the dependencies")
+	assert.False(t, strings.Contains(w.Body.String(), "redis"), "Expected liveness not to run the checks")
+
+	failing = nil
+	assert.Equal(t, http.StatusOK, testReady(srv))
+}
@@ -0,0 +1,219 @@
+// Package template renders user provided response templates with a
+// restricted function set
+package template
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	text "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxOutput caps the size of a rendered template in bytes
+	MaxOutput = 64 << 10
+	// MaxSteps caps the range iterations and template calls of a render
+	MaxSteps = 10000
+	// MaxDuration caps the time spent rendering a template
+	MaxDuration = 100 * time.Millisecond
+
+	// tick counts the steps of a render, it is called at the start of every
+	// range iteration and template call
+	tick = "_tick"
+)
+
+// ErrOutputTooLarge is returned when a template renders more than MaxOutput
+var ErrOutputTooLarge = errors.New("template output too large")
+
+// ErrTooExpensive is returned when a template takes more than MaxSteps or
+// MaxDuration to render
+var ErrTooExpensive = errors.New("template too expensive to render")
+
+// tickNode is the action calling tick, inserted in the parsed templates
+var tickNode = text.Must(text.New(tick).Funcs(text.FuncMap{tick: noTick}).Parse("{{" + tick + "}}")).Tree.Root.Nodes[0]
+
+func noTick() (string, error) {
+	return "", nil
+}
+
+// Data is the captured request a template can reference. Fields missing from
+// JSON, Query or Headers render as "<no value>", the default function
+// substitutes a fallback: {{.JSON.name | default "anonymous"}}.
+type Data struct {
+	Method   string
+	Path     string
+	Query    url.Values
+	Headers  http.Header
+	Body     string
+	JSON     interface{}
+	RemoteIP string
+}
+
+// funcs is the only set of functions available besides the text/template
+// builtins. None of them touch the filesystem, network or environment.
+var funcs = text.FuncMap{
+	"uuid":      func() string { return uuid.New().String() },
+	"now":       func() time.Time { return time.Now().UTC() },
+	"unix":      func() int64 { return time.Now().Unix() },
+	"timestamp": func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"trim":      strings.TrimSpace,
+	"base64":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64decode": func(s string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		return string(decoded), err
+	},
+	"sha256": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	},
+	"hmacSHA256": func(key string, message string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(message))
+		return hex.EncodeToString(mac.Sum(nil))
+	},
+	"json": func(v interface{}) (string, error) {
+		marshalled, err := json.Marshal(v)
+		return string(marshalled), err
+	},
+	"default": func(fallback interface{}, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+	tick: noTick,
+}
+
+// NewData converts a captured request into template data. JSON holds the
+// decoded body when it is valid json.
+func NewData(method string, path string, query string, headers http.Header, body []byte, remoteIP string) *Data {
+	values, _ := url.ParseQuery(query)
+
+	data := &Data{
+		Method:   method,
+		Path:     path,
+		Query:    values,
+		Headers:  headers,
+		Body:     string(body),
+		RemoteIP: remoteIP,
+	}
+
+	var decoded interface{}
+	if json.Unmarshal(body, &decoded) == nil {
+		data.JSON = decoded
+	}
+
+	return data
+}
+
+// Parse validates the template. Ranging over a number is refused, it would
+// only spin.
+func Parse(source string) (*text.Template, error) {
+	tmpl, err := text.New("response").Funcs(funcs).Option("missingkey=default").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+		if err := instrument(defined.Tree.Root); err != nil {
+			return nil, err
+		}
+		defined.Tree.Root.Nodes = append([]parse.Node{tickNode}, defined.Tree.Root.Nodes...)
+	}
+
+	return tmpl, nil
+}
+
+// Render executes the template against data, within MaxSteps and MaxDuration
+func Render(source string, data *Data) (string, error) {
+	tmpl, err := Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	steps, deadline := 0, time.Now().Add(MaxDuration)
+	tmpl.Funcs(text.FuncMap{tick: func() (string, error) {
+		steps++
+		if steps > MaxSteps || time.Now().After(deadline) {
+			return "", ErrTooExpensive
+		}
+		return "", nil
+	}})
+
+	out := &limitedBuffer{}
+	if err := tmpl.Execute(out, data); err != nil {
+		if errors.Is(err, ErrTooExpensive) {
+			return "", ErrTooExpensive
+		}
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// instrument calls tick at the start of every range iteration below node,
+// refusing the ranges over a number
+func instrument(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := instrument(child); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return instrumentBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return instrumentBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		if cmds := n.Pipe.Cmds; len(cmds) == 1 && len(cmds[0].Args) == 1 {
+			if _, ok := cmds[0].Args[0].(*parse.NumberNode); ok {
+				return errors.New("template: range over a number is not allowed")
+			}
+		}
+		if err := instrumentBranch(&n.BranchNode); err != nil {
+			return err
+		}
+		n.List.Nodes = append([]parse.Node{tickNode}, n.List.Nodes...)
+	}
+
+	return nil
+}
+
+func instrumentBranch(branch *parse.BranchNode) error {
+	if err := instrument(branch.List); err != nil {
+		return err
+	}
+	return instrument(branch.ElseList)
+}
+
+// limitedBuffer fails writes past MaxOutput
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > MaxOutput {
+		return 0, ErrOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,106 @@
+package template_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/common/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func testData() *template.Data {
+	headers := http.Header{}
+	headers.Set("X-Delivery", "abc")
+
+	return template.NewData(
+		"POST",
+		"/events",
+		"hub.challenge=1158201444&validationToken=Validation%3A+Token",
+		headers,
+		[]byte(`{"type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","event":{"id":7}}`),
+		"10.0.0.1",
+	)
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"static", "ok", "ok"},
+		{"slack challenge", `{{.JSON.challenge}}`, "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"},
+		{"nested json", `{{.JSON.event.id}}`, "7"},
+		{"facebook hub challenge", `{{index .Query "hub.challenge" 0}}`, "1158201444"},
+		{"graph validation token", `{{.Query.Get "validationToken"}}`, "Validation: Token"},
+		{"header", `{{.Headers.Get "x-delivery"}}`, "abc"},
+		{"request line", `{{.Method}} {{.Path}} {{.RemoteIP}}`, "POST /events 10.0.0.1"},
+		{"missing json field", `{{.JSON.missing}}`, "<no value>"},
+		{"default", `{{.JSON.missing | default "none"}} {{.JSON.type | default "none"}}`, "none url_verification"},
+		{"range", `{{range $k, $v := .JSON.event}}{{$k}}={{$v}}{{end}}`, "id=7"},
+		{"functions", `{{upper "a"}}{{lower "B"}}{{base64 "hi"}}{{sha256 ""}}`, "AbaGk=e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"hmac", `{{hmacSHA256 "key" "message"}}`, "6e9ef29b75fffc5b7abae527d58fdadb2fe42e7219011976917343065f58ed4a"},
+		{"json", `{{json .JSON.event}}`, `{"id":7}`},
+	}
+
+	data := testData()
+	for _, test := range tests {
+		rendered, err := template.Render(test.source, data)
+		assert.Nil(t, err, test.name)
+		assert.Equal(t, test.expected, rendered, test.name)
+	}
+}
+
+func TestRenderGenerated(t *testing.T) {
+	rendered, err := template.Render(`{{uuid}}`, testData())
+	assert.Nil(t, err)
+	_, err = uuid.Parse(rendered)
+	assert.Nil(t, err, "Expected a uuid")
+
+	rendered, err = template.Render(`{{timestamp}}`, testData())
+	assert.Nil(t, err)
+	assert.Equal(t, 20, len(rendered))
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := map[string]string{
+		"syntax":           `{{.JSON.challenge`,
+		"unknown function": `{{env "HOME"}}`,
+		"output too large": `{{printf "%070000d" 0}}`,
+	}
+
+	for name, source := range tests {
+		_, err := template.Render(source, testData())
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := template.Render(strings.Repeat("a", template.MaxOutput+1), testData())
+	assert.Equal(t, template.ErrOutputTooLarge, err)
+}
+
+func TestRenderBudget(t *testing.T) {
+	_, err := template.Parse(`{{range 100000000000000}}{{end}}`)
+	assert.NotNil(t, err, "Expected ranges over a number to be refused")
+	_, err = template.Parse(`{{if true}}{{range 3}}{{end}}{{end}}`)
+	assert.NotNil(t, err, "Expected nested ranges over a number to be refused")
+
+	data := template.NewData("POST", "/", "", http.Header{}, []byte("["+strings.Repeat("1,", 1000)+"1]"), "")
+	tests := map[string]string{
+		"nested ranges":      `{{range .JSON}}{{range $.JSON}}{{end}}{{end}}`,
+		"computed range":     `{{range unix}}{{end}}`,
+		"recursive template": `{{define "a"}}{{template "a" .}}{{template "a" .}}{{end}}{{template "a" .}}`,
+	}
+
+	for name, source := range tests {
+		start := time.Now()
+		_, err := template.Render(source, data)
+		assert.Equal(t, template.ErrTooExpensive, err, name)
+		assert.True(t, time.Since(start) < time.Second, name)
+	}
+
+	_, err = template.Render(`{{range .JSON}}{{.}}{{end}}`, data)
+	assert.Nil(t, err, "Expected a single range over the body to fit the budget")
+}
@@ -0,0 +1,78 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testRecorder(t *testing.T) *tracetest.SpanRecorder {
+	_, err := tracing.Setup(context.Background(), config.Default().Tracing)
+	assert.Nil(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func TestPropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := testRecorder(t)
+
+	var traceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	router := gin.New()
+	router.Use(tracing.Middleware("hooks-api"))
+	router.GET("/b/:binID", func(c *gin.Context) {
+		ctx, span := tracing.Start(c.Request.Context(), "CacheRepo.Lookup")
+		tracing.End(span, errors.New("refused"))
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", downstream.URL, nil)
+		resp, err := tracing.Client(0).Do(req)
+		if assert.Nil(t, err) {
+			resp.Body.Close()
+		}
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest("GET", "/b/first", nil)
+	req.Header.Set("traceparent", parent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if !assert.Equal(t, 3, len(spans)) {
+		return
+	}
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "Expected the incoming trace to continue")
+	}
+	assert.Equal(t, "CacheRepo.Lookup", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "GET /b/:binID", spans[2].Name(), "Expected the route instead of the path")
+	assert.Contains(t, traceparent, "4bf92f3577b34da6a3ce929d0e0e4736", "Expected the trace to propagate downstream")
+}
+
+func TestSetup(t *testing.T) {
+	flush, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "stdout", ServiceName: "hooks-api"})
+	assert.Nil(t, err)
+	assert.Nil(t, flush())
+
+	flush, err = tracing.Setup(context.Background(), config.Tracing{Exporter: "none"})
+	assert.Nil(t, err)
+	assert.Nil(t, flush())
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("create_bin", &gormigrate.Migration{
+		ID: "202610170000",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				ID        string `gorm:"primary_key;type:char(36)"`
+				Title     string `gorm:"size:255;not null"`
+				AccountID string `gorm:"type:char(36);not null;index:idx_account_id"`
+				CreatedAt *time.Time
+				UpdatedAt *time.Time
+			}
+			// databases created before versioned migrations already have
+			// the table, which is left as is
+			return tx.Table("bin").AutoMigrate(&bin{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTable("bin").Error
+		},
+	})
+}
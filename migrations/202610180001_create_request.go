@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("create_request", &gormigrate.Migration{
+		ID: "202610180001",
+		Migrate: func(tx *gorm.DB) error {
+			type request struct {
+				ID        string `gorm:"primary_key;type:char(36)"`
+				BinID     string `gorm:"type:char(36);not null;index:idx_bin_id"`
+				Method    string `gorm:"size:16;not null"`
+				Path      string `gorm:"type:text"`
+				Query     string `gorm:"type:text"`
+				Headers   string `gorm:"type:text"`
+				Body      []byte
+				RemoteIP  string `gorm:"size:45"`
+				CreatedAt *time.Time
+			}
+			return tx.Table("request").AutoMigrate(&request{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTable("request").Error
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("create_replay", &gormigrate.Migration{
+		ID: "202610180002",
+		Migrate: func(tx *gorm.DB) error {
+			type replay struct {
+				ID              string `gorm:"primary_key;type:char(36)"`
+				RequestID       string `gorm:"type:char(36);not null;index:idx_request_id"`
+				URL             string `gorm:"type:text;not null"`
+				Method          string `gorm:"size:16;not null"`
+				RequestHeaders  string `gorm:"type:text"`
+				RequestBody     []byte
+				StatusCode      int
+				ResponseHeaders string `gorm:"type:text"`
+				ResponseBody    []byte
+				Latency         int64
+				Error           string `gorm:"type:text"`
+				CreatedAt       *time.Time
+			}
+			return tx.Table("replay").AutoMigrate(&replay{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTable("replay").Error
+		},
+	})
+}
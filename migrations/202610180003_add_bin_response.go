@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("add_bin_response", &gormigrate.Migration{
+		ID: "202610180003",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				ResponseStatus      int    `gorm:"not null;default:200"`
+				ResponseHeaders     string `gorm:"type:text"`
+				ResponseBody        string `gorm:"type:text"`
+				ResponseContentType string `gorm:"size:255"`
+				ResponseDelay       int    `gorm:"not null;default:0"`
+			}
+			return tx.Table("bin").AutoMigrate(&bin{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return dropColumns(tx, "bin", "response_status", "response_headers", "response_body", "response_content_type", "response_delay")
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("add_bin_scenario", &gormigrate.Migration{
+		ID: "202610180004",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				ResponseTimeout     bool    `gorm:"not null;default:false"`
+				ScenarioMode        string  `gorm:"size:16"`
+				ScenarioSteps       string  `gorm:"type:text"`
+				ScenarioFailureRate float64 `gorm:"not null;default:0"`
+			}
+			return tx.Table("bin").AutoMigrate(&bin{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return dropColumns(tx, "bin", "response_timeout", "scenario_mode", "scenario_steps", "scenario_failure_rate")
+		},
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("add_bin_response_template", &gormigrate.Migration{
+		ID: "202610180005",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				ResponseTemplate bool `gorm:"not null;default:false"`
+			}
+			return tx.Table("bin").AutoMigrate(&bin{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return dropColumns(tx, "bin", "response_template")
+		},
+	})
+}
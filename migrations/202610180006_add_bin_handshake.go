@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("add_bin_handshake", &gormigrate.Migration{
+		ID: "202610180006",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				HandshakeProvider string `gorm:"size:32"`
+				HandshakeSecret   string `gorm:"size:255"`
+			}
+			return tx.Table("bin").AutoMigrate(&bin{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return dropColumns(tx, "bin", "handshake_provider", "handshake_secret")
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("add_verification", &gormigrate.Migration{
+		ID: "202610180007",
+		Migrate: func(tx *gorm.DB) error {
+			type bin struct {
+				VerificationProvider  string `gorm:"size:32"`
+				VerificationSecret    string `gorm:"size:255"`
+				VerificationTolerance int    `gorm:"not null;default:0"`
+				VerificationReject    bool   `gorm:"not null;default:false"`
+			}
+			type request struct {
+				URL     string `gorm:"type:text"`
+				Verdict string `gorm:"size:16"`
+				Reason  string `gorm:"type:text"`
+			}
+			if err := tx.Table("bin").AutoMigrate(&bin{}).Error; err != nil {
+				return err
+			}
+			return tx.Table("request").AutoMigrate(&request{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, "bin", "verification_provider", "verification_secret", "verification_tolerance", "verification_reject"); err != nil {
+				return err
+			}
+			return dropColumns(tx, "request", "url", "verdict", "reason")
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+	"time"
+)
+
+// idLayout stamps the ID of new migrations, IDs sort in creation order
+const idLayout = "200601021504"
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var skeleton = template.Must(template.New("migration").Parse(`package migrations
+
+import (
+	"github.com/jinzhu/gorm"
+	gormigrate "gopkg.in/gormigrate.v1"
+)
+
+func init() {
+	register("{{.Name}}", &gormigrate.Migration{
+		ID: "{{.ID}}",
+		Migrate: func(tx *gorm.DB) error {
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+
+// Create writes a new migration named name to dir and returns its path. Its
+// ID is the creation time, in minutes.
+func Create(dir string, name string, now time.Time) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q, use lowercase letters, digits and underscores", name)
+	}
+
+	ID := now.UTC().Format(idLayout)
+	if find(ID) != nil {
+		return "", fmt.Errorf("migration %s already exists", ID)
+	}
+
+	path := filepath.Join(dir, ID+"_"+name+".go")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	err = skeleton.Execute(file, struct {
+		ID   string
+		Name string
+	}{ID: ID, Name: name})
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
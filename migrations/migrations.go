@@ -1,41 +1,261 @@
+// Package migrations versions the database schema. Every migration lives in
+// its own file, named after its ID, and declares the columns it changes
+// rather than reading the current models, so that replaying the history
+// always builds the same schema.
 package migrations
 
 import (
-	"github.com/hugocortes/hooks-api/bins/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
 	"github.com/jinzhu/gorm"
 	"github.com/sirupsen/logrus"
 	gormigrate "gopkg.in/gormigrate.v1"
 )
 
-var m *gormigrate.Gormigrate
+const (
+	// lockKey identifies the advisory lock held by the instance migrating
+	// Postgres
+	lockKey = 4871250369
+)
+
+// State tells whether a migration was applied
+type State struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+type migration struct {
+	*gormigrate.Migration
+	name string
+}
 
-// Run initializes the schema and runs any additional migrations
+var registered []*migration
+
+// register adds a migration. Every migration file registers itself in init.
+func register(name string, m *gormigrate.Migration) {
+	registered = append(registered, &migration{Migration: m, name: name})
+}
+
+// Run applies every pending migration, exiting when one fails
 func Run(db *gorm.DB) {
-	m = gormigrate.New(db, gormigrate.DefaultOptions, []*gormigrate.Migration{
-		// migrations
+	if _, err := Up(db); err != nil {
+		logrus.Fatal("Could not migrate: ", err)
+	}
+	logrus.Debug("Migration √")
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the IDs applied
+func Up(db *gorm.DB) ([]string, error) {
+	var done []string
+	err := locked(db, func() error {
+		pending, err := Pending(db)
+		if err != nil && !errors.Is(err, errNeverRan) {
+			return err
+		}
+
+		for _, ID := range pending {
+			err := transaction(db, func(tx *gorm.DB) error {
+				return gormigrate.New(tx, options(), list()).MigrateTo(ID)
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %w", ID, err)
+			}
+			done = append(done, ID)
+		}
+
+		return nil
 	})
 
-	initSchema()
-	migrate()
+	return done, err
 }
 
-func initSchema() {
-	m.InitSchema(func(tx *gorm.DB) error {
-		err := tx.AutoMigrate(
-			&models.Bin{},
-		).Error
+// Down rolls back the migrations applied after to, in reverse order and each
+// in its own transaction, and returns the IDs rolled back. Only the last
+// applied migration is rolled back when to is empty.
+func Down(db *gorm.DB, to string) ([]string, error) {
+	if to != "" && find(to) == nil {
+		return nil, fmt.Errorf("unknown migration %s", to)
+	}
+
+	var done []string
+	err := locked(db, func() error {
+		states, err := Status(db)
 		if err != nil {
 			return err
 		}
 
-		logrus.Debug("Initialize schema √")
+		for i := len(states) - 1; i >= 0 && states[i].ID != to; i-- {
+			if !states[i].Applied {
+				continue
+			}
+
+			m := find(states[i].ID)
+			err := transaction(db, func(tx *gorm.DB) error {
+				return gormigrate.New(tx, options(), list()).RollbackMigration(m.Migration)
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %s failed: %w", m.ID, err)
+			}
+			done = append(done, m.ID)
+
+			if to == "" {
+				return nil
+			}
+		}
+
 		return nil
 	})
+
+	return done, err
 }
 
-func migrate() {
-	if err := m.Migrate(); err != nil {
-		logrus.Fatal("Could not migrate: ", err)
+// Status lists every migration in order
+func Status(db *gorm.DB) ([]State, error) {
+	applied, err := applied(db)
+	if err != nil && !errors.Is(err, errNeverRan) {
+		return nil, err
 	}
-	logrus.Debug("Migration √")
+
+	var states []State
+	for _, m := range all() {
+		states = append(states, State{ID: m.ID, Name: m.name, Applied: applied[m.ID]})
+	}
+
+	return states, nil
+}
+
+// Pending lists the migrations not applied to the database yet
+func Pending(db *gorm.DB) ([]string, error) {
+	applied, err := applied(db)
+
+	var pending []string
+	for _, m := range all() {
+		if !applied[m.ID] {
+			pending = append(pending, m.ID)
+		}
+	}
+
+	return pending, err
+}
+
+var errNeverRan = errors.New("migrations have never run")
+
+// applied returns the IDs of the applied migrations
+func applied(db *gorm.DB) (map[string]bool, error) {
+	options := options()
+	if !db.HasTable(options.TableName) {
+		return map[string]bool{}, errNeverRan
+	}
+
+	var IDs []string
+	err := db.Table(options.TableName).Pluck(options.IDColumnName, &IDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]bool{}
+	for _, ID := range IDs {
+		applied[ID] = true
+	}
+
+	return applied, nil
+}
+
+// all returns the migrations ordered by ID
+func all() []*migration {
+	sorted := append([]*migration{}, registered...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	return sorted
+}
+
+func list() []*gormigrate.Migration {
+	var migrations []*gormigrate.Migration
+	for _, m := range all() {
+		migrations = append(migrations, m.Migration)
+	}
+
+	return migrations
+}
+
+func find(ID string) *migration {
+	for _, m := range registered {
+		if m.ID == ID {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// options leaves the transactions to transaction, so that each migration
+// commits on its own
+func options() *gormigrate.Options {
+	options := *gormigrate.DefaultOptions
+	options.UseTransaction = false
+
+	return &options
+}
+
+// transaction runs fn in a transaction, committed unless fn fails
+func transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// locked runs fn holding the migration lock, so that instances starting
+// together do not race. Postgres holds an advisory lock for the session of a
+// dedicated connection, SQLite already locks its file on every write.
+func locked(db *gorm.DB, fn func() error) error {
+	if db.Dialect().GetName() != "postgres" {
+		return fn()
+	}
+
+	ctx := context.Background()
+	conn, err := db.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&acquired); err != nil {
+		return err
+	}
+	if !acquired {
+		logrus.Info("waiting for another instance to migrate")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return err
+		}
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+
+	return fn()
+}
+
+// dropColumns drops the columns of table, in order
+func dropColumns(tx *gorm.DB, table string, columns ...string) error {
+	for _, column := range columns {
+		if err := tx.Table(table).DropColumn(column).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
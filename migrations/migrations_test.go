@@ -0,0 +1,129 @@
+package migrations_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/migrations"
+	requestModels "github.com/hugocortes/hooks-api/requests/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDatabase(t *testing.T) *gorm.DB {
+	cfg := config.Default()
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "hooks.db")
+	db := deps.SQLite(cfg)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testPending(t *testing.T, db *gorm.DB) []string {
+	var pending []string
+	states, err := migrations.Status(db)
+	assert.Nil(t, err)
+	for _, state := range states {
+		if !state.Applied {
+			pending = append(pending, state.ID)
+		}
+	}
+	return pending
+}
+
+func TestUpDown(t *testing.T) {
+	db := testDatabase(t)
+
+	applied, err := migrations.Up(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(applied))
+	assert.Equal(t, "202610170000", applied[0], "Expected the bin table first")
+	assert.Empty(t, testPending(t, db))
+	assert.True(t, db.Table("bin").Dialect().HasColumn("bin", "verification_reject"))
+
+	// the schema built from the history fits the current models
+	bin := &models.Bin{ID: "00000000-0000-4000-8000-000000000001", Title: "migrated", AccountID: config.DevAccountID}
+	assert.Nil(t, db.Create(bin).Error)
+	assert.Nil(t, db.Create(&requestModels.Request{ID: "00000000-0000-4000-8000-000000000002", BinID: bin.ID, Method: "POST"}).Error)
+
+	applied, err = migrations.Up(db)
+	assert.Nil(t, err)
+	assert.Empty(t, applied, "Expected nothing left to apply")
+
+	rolledBack, err := migrations.Down(db, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"202610180007"}, rolledBack, "Expected the last migration only")
+	assert.False(t, db.Dialect().HasColumn("bin", "verification_reject"))
+	assert.False(t, db.Dialect().HasColumn("request", "verdict"))
+
+	rolledBack, err = migrations.Down(db, "202610180003")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"202610180006", "202610180005", "202610180004"}, rolledBack)
+	assert.True(t, db.Dialect().HasColumn("bin", "response_status"), "Expected the target migration to be kept")
+	assert.False(t, db.Dialect().HasColumn("bin", "scenario_mode"))
+	assert.Equal(t, []string{"202610180004", "202610180005", "202610180006", "202610180007"}, testPending(t, db))
+
+	applied, err = migrations.Up(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(applied))
+
+	var count int
+	db.Table("bin").Count(&count)
+	assert.Equal(t, 1, count, "Expected the rows to survive the round trip")
+
+	_, err = migrations.Down(db, "202601010000")
+	assert.NotNil(t, err, "Expected unknown migrations to be refused")
+}
+
+func TestUpAdoptsInitSchema(t *testing.T) {
+	db := testDatabase(t)
+
+	// the schema was built from the models before migrations were versioned
+	assert.Nil(t, db.AutoMigrate(&models.Bin{}, &requestModels.Request{}, &requestModels.Replay{}).Error)
+	assert.Nil(t, db.Exec("CREATE TABLE migrations (id VARCHAR(255) PRIMARY KEY)").Error)
+	for _, ID := range []string{"SCHEMA_INIT", "202610180001", "202610180002", "202610180003", "202610180004", "202610180005", "202610180006", "202610180007"} {
+		assert.Nil(t, db.Exec("INSERT INTO migrations (id) VALUES (?)", ID).Error)
+	}
+
+	applied, err := migrations.Up(db)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"202610170000"}, applied)
+	assert.Empty(t, testPending(t, db))
+}
+
+func TestPending(t *testing.T) {
+	db := testDatabase(t)
+
+	pending, err := migrations.Pending(db)
+	assert.NotNil(t, err, "Expected a database never migrated to be reported")
+	assert.Equal(t, 8, len(pending))
+	assert.Equal(t, 8, len(testPending(t, db)), "Expected status to list every migration as pending")
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+
+	path, err := migrations.Create(dir, "add_bin_tags", now)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "202610191230_add_bin_tags.go"), path)
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `register("add_bin_tags"`)
+	assert.Contains(t, string(content), `ID: "202610191230"`)
+	_, err = parser.ParseFile(token.NewFileSet(), path, content, 0)
+	assert.Nil(t, err, "Expected the migration to parse")
+
+	_, err = migrations.Create(dir, "add_bin_tags", now)
+	assert.NotNil(t, err, "Expected an existing migration to be kept")
+
+	_, err = migrations.Create(dir, "Add tags", now)
+	assert.NotNil(t, err)
+}
@@ -1,9 +1,89 @@
 package models
 
-// QueryOpts ...
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 25
+
+	// OrderAsc sorts the listings in ascending order, the default
+	OrderAsc = "asc"
+	// OrderDesc sorts the listings in descending order
+	OrderDesc = "desc"
+)
+
+// QueryOpts selects a page of a listing, either by offset or by cursor. The
+// sort, order and filters only apply to the listings parsed by ParseListOpts.
 type QueryOpts struct {
 	Page  int
 	Limit int
+
+	Cursor        *Cursor
+	Sort          string
+	Order         string
+	Title         string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Total         bool
+}
+
+// Cursor is the position of a row in a sorted listing, the row whose sort
+// field holds Value and whose ID is ID. The page starts right after the row,
+// or ends right before it when Before is set. Clients get it encoded and
+// send it back as is.
+type Cursor struct {
+	Sort   string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     string `json:"i"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor encoded by Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.ID == "" {
+		return nil, errors.New("cursor is invalid")
+	}
+
+	return cursor, nil
+}
+
+// Descending tells whether the listing is sorted in descending order
+func (db *QueryOpts) Descending() bool {
+	return db.Order == OrderDesc
+}
+
+// Key identifies the page selected, so that it can be cached
+func (db *QueryOpts) Key() string {
+	raw, _ := json.Marshal(struct {
+		*QueryOpts
+		Offset int
+		Limit  int
+	}{QueryOpts: db, Offset: db.GetOffset(), Limit: db.GetLimit()})
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
 }
 
 // GetOffset returns the page offset required for query
@@ -25,3 +105,105 @@ func (db *QueryOpts) GetLimit() int {
 	}
 	return db.Limit
 }
+
+// ParseQueryOpts parses the page and limit query params. Empty values fall
+// back to the first page and the default limit.
+func ParseQueryOpts(page string, limit string) (*QueryOpts, error) {
+	opts := &QueryOpts{Page: 0, Limit: defaultLimit}
+
+	var err error
+	if page != "" {
+		if opts.Page, err = strconv.Atoi(page); err != nil || opts.Page < 0 {
+			return nil, errors.New("page must be a non-negative integer")
+		}
+	}
+	if limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit < 1 {
+			return nil, errors.New("limit must be a positive integer")
+		}
+	}
+
+	return opts, nil
+}
+
+// ParseListOpts parses the page and limit query params along with the cursor,
+// sort, order, title, created_after, created_before and total ones. The sort
+// must be one of sorts, the first by default. A cursor carries the sort and
+// order of the listing it was returned with and cannot be combined with a
+// page or another sort.
+func ParseListOpts(query url.Values, sorts ...string) (*QueryOpts, error) {
+	opts, err := ParseQueryOpts(query.Get("page"), query.Get("limit"))
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Sort = query.Get("sort")
+	if opts.Sort != "" && !contains(sorts, opts.Sort) {
+		return nil, fmt.Errorf("sort must be one of %v", sorts)
+	}
+	opts.Order = query.Get("order")
+	if opts.Order != "" && opts.Order != OrderAsc && opts.Order != OrderDesc {
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if query.Get("page") != "" {
+			return nil, errors.New("cursor and page cannot be combined")
+		}
+		if opts.Cursor, err = DecodeCursor(cursor); err != nil {
+			return nil, err
+		}
+		if !contains(sorts, opts.Cursor.Sort) ||
+			(opts.Cursor.Order != OrderAsc && opts.Cursor.Order != OrderDesc) {
+			return nil, errors.New("cursor is invalid")
+		}
+		if (opts.Sort != "" && opts.Sort != opts.Cursor.Sort) || (opts.Order != "" && opts.Order != opts.Cursor.Order) {
+			return nil, errors.New("cursor was returned for another sort")
+		}
+		opts.Sort, opts.Order = opts.Cursor.Sort, opts.Cursor.Order
+	}
+	if opts.Sort == "" && len(sorts) > 0 {
+		opts.Sort = sorts[0]
+	}
+	if opts.Order == "" {
+		opts.Order = OrderAsc
+	}
+
+	opts.Title = query.Get("title")
+	if opts.CreatedAfter, err = parseTime(query.Get("created_after")); err != nil {
+		return nil, errors.New("created_after must be an RFC 3339 time")
+	}
+	if opts.CreatedBefore, err = parseTime(query.Get("created_before")); err != nil {
+		return nil, errors.New("created_before must be an RFC 3339 time")
+	}
+
+	if total := query.Get("total"); total != "" {
+		if opts.Total, err = strconv.ParseBool(total); err != nil {
+			return nil, errors.New("total must be a boolean")
+		}
+	}
+
+	return opts, nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package requests
+
+import (
+	"context"
+
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// Handler ...
+type Handler interface {
+	Capture(ctx context.Context, binID string, request *models.Request) (*binModels.Response, error)
+	Owns(ctx context.Context, accountID string, binID string) (bool, error)
+	GetAll(binID string, opts *gModels.QueryOpts) ([]*models.Request, error)
+	Get(binID string, ID string) (*models.Request, error)
+	Tail(ctx context.Context, binID string, lastEventID string) (<-chan *models.Request, error)
+	Replay(ctx context.Context, binID string, ID string, options *models.ReplayOptions) (*models.Replay, error)
+	GetReplays(ID string) ([]*models.Replay, error)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hugocortes/hooks-api/bins"
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/handshakes"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/hugocortes/hooks-api/requests/signatures"
+)
+
+const (
+	maxBacklog = 100
+)
+
+// Handler provides the captured request use cases
+type Handler struct {
+	repo   *requests.Repository
+	bins   *bins.Repository
+	client *http.Client
+}
+
+// New ... Captured requests may be replayed to the public addresses and to
+// the allowed networks.
+func New(repo *requests.Repository, binRepo *bins.Repository, allowed []*net.IPNet) *Handler {
+	return &Handler{
+		repo:   repo,
+		bins:   binRepo,
+		client: replayClient(allowed),
+	}
+}
+
+// Capture stores the request in the bin and returns the response the bin
+// answers with. A nil response is returned when the bin does not exist, in
+// which case nothing is stored.
+func (h *Handler) Capture(ctx context.Context, binID string, request *models.Request) (*binModels.Response, error) {
+	bin, err := h.bins.DB.Lookup(ctx, binID)
+	if errors.Is(err, bins.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verify(bin, request)
+	if err := h.repo.DB.Create(bin.ID, request); err != nil {
+		return nil, err
+	}
+
+	if err := h.repo.Stream.Publish(request); err != nil {
+		logging.FromContext(ctx).Warn("failed to publish captured request: ", err)
+	}
+
+	if response, ok := handshake(ctx, bin, request); ok {
+		return response, nil
+	}
+
+	if bin.Verification.Reject && request.Verdict != models.VerdictVerified {
+		return &binModels.Response{
+			Status:      http.StatusUnauthorized,
+			Body:        "signature " + request.Verdict + ": " + request.Reason,
+			ContentType: "text/plain; charset=utf-8",
+		}, nil
+	}
+
+	return render(ctx, h.respond(ctx, bin), request), nil
+}
+
+// verify records the signature verdict of the bin provider on the request
+func verify(bin *binModels.Bin, request *models.Request) {
+	if bin.Verification.Provider == "" {
+		return
+	}
+
+	verifier, ok := signatures.Get(bin.Verification.Provider)
+	if !ok {
+		request.Verdict = models.VerdictFailed
+		request.Reason = "unknown provider " + bin.Verification.Provider
+		return
+	}
+
+	request.Verdict, request.Reason = signatures.Verdict(verifier, request, &signatures.Options{
+		Secret:    bin.Verification.Secret,
+		Tolerance: time.Duration(bin.Verification.Tolerance) * time.Second,
+		Now:       time.Now(),
+	})
+}
+
+// handshake answers the verification requests of the bin provider
+func handshake(ctx context.Context, bin *binModels.Bin, request *models.Request) (*binModels.Response, bool) {
+	if bin.Handshake.Provider == "" {
+		return nil, false
+	}
+
+	provider, ok := handshakes.Get(bin.Handshake.Provider)
+	if !ok {
+		logging.FromContext(ctx).Warn("unknown handshake provider: ", bin.Handshake.Provider)
+		return nil, false
+	}
+
+	return provider.Answer(ctx, request, bin.Handshake.Secret)
+}
+
+// respond picks the response of the bin scenario. The bin response is used
+// when the scenario position is unavailable.
+func (h *Handler) respond(ctx context.Context, bin *binModels.Bin) *binModels.Response {
+	var position int64
+	if bin.Sequenced() {
+		next, err := h.bins.Sequence.Next(bin.ID)
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to read bin sequence: ", err)
+			return &bin.Response
+		}
+		position = next
+	}
+
+	return bin.Pick(position, rand.Float64())
+}
+
+// Owns reports whether the bin belongs to the account
+func (h *Handler) Owns(ctx context.Context, accountID string, binID string) (bool, error) {
+	_, err := h.bins.DB.Get(ctx, accountID, binID)
+	if errors.Is(err, bins.ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// GetAll returns a page of captured requests, newest first
+func (h *Handler) GetAll(binID string, opts *gModels.QueryOpts) ([]*models.Request, error) {
+	return h.repo.DB.GetAll(binID, opts)
+}
+
+// Get returns the captured request or nil if the bin has no such request
+func (h *Handler) Get(binID string, ID string) (*models.Request, error) {
+	return h.repo.DB.Get(binID, ID)
+}
+
+// Tail returns the requests captured by the bin until ctx is done. When
+// lastEventID is set, the requests captured after it are replayed first.
+func (h *Handler) Tail(ctx context.Context, binID string, lastEventID string) (<-chan *models.Request, error) {
+	// subscribe before reading the backlog so nothing is missed in between
+	ctx, cancel := context.WithCancel(ctx)
+	live, err := h.repo.Stream.Subscribe(ctx, binID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var backlog []*models.Request
+	if lastEventID != "" {
+		backlog, err = h.repo.DB.GetSince(binID, lastEventID, maxBacklog)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	tail := make(chan *models.Request)
+	go func() {
+		defer close(tail)
+		defer cancel()
+
+		sent := map[string]bool{}
+		for _, request := range backlog {
+			sent[request.ID] = true
+			select {
+			case tail <- request:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for request := range live {
+			if sent[request.ID] {
+				continue
+			}
+			select {
+			case tail <- request:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return tail, nil
+}
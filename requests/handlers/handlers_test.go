@@ -0,0 +1,254 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	binMocks "github.com/hugocortes/hooks-api/bins/mocks"
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/handlers"
+	"github.com/hugocortes/hooks-api/requests/mocks"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// channelStream delivers published requests to a single subscriber
+type channelStream struct {
+	live chan *models.Request
+}
+
+func (s *channelStream) Publish(request *models.Request) error {
+	s.live <- request
+	return nil
+}
+
+func (s *channelStream) Subscribe(ctx context.Context, binID string) (<-chan *models.Request, error) {
+	out := make(chan *models.Request)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case request := <-s.live:
+				select {
+				case out <- request:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+var mockDB *mocks.DB
+var mockBins *binMocks.DB
+var mockSequence *binMocks.Sequence
+var mockReplays *mocks.ReplayDB
+var testStream *channelStream
+var testHandler *handlers.Handler
+
+// testLoopback lets the handler replay to the test servers
+var testLoopback = []*net.IPNet{
+	{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+}
+
+func testHandlerSetup() {
+	mockDB = new(mocks.DB)
+	mockReplays = new(mocks.ReplayDB)
+	mockBins = new(binMocks.DB)
+	mockSequence = new(binMocks.Sequence)
+	testStream = &channelStream{live: make(chan *models.Request, 10)}
+	testHandler = handlers.New(
+		&requests.Repository{DB: mockDB, Replays: mockReplays, Stream: testStream},
+		&bins.Repository{DB: mockBins, Sequence: mockSequence},
+		testLoopback,
+	)
+}
+
+func testReceive(t *testing.T, tail <-chan *models.Request) *models.Request {
+	select {
+	case request := <-tail:
+		return request
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for request")
+		return nil
+	}
+}
+
+func TestCaptureScenario(t *testing.T) {
+	testHandlerSetup()
+
+	bin := &binModels.Bin{
+		ID:       uuid.New().String(),
+		Response: binModels.Response{Status: 200},
+		Scenario: binModels.Scenario{
+			Mode:  binModels.ScenarioOnce,
+			Steps: binModels.Responses{{Status: 500}, {Status: 500}},
+		},
+	}
+	mockBins.On("Lookup", mock.Anything, bin.ID).Return(bin, nil)
+	mockDB.On("Create", bin.ID, mock.Anything).Return(nil)
+
+	var position int64
+	mockSequence.On("Next", bin.ID).Return(func(string) int64 {
+		position++
+		return position
+	}, nil)
+
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		response, err := testHandler.Capture(context.Background(), bin.ID, &models.Request{})
+		assert.Nil(t, err)
+		statuses = append(statuses, response.Status)
+		<-testStream.live
+	}
+	assert.Equal(t, []int{500, 500, 200}, statuses)
+}
+
+func TestCaptureSequenceUnavailable(t *testing.T) {
+	testHandlerSetup()
+
+	bin := &binModels.Bin{
+		ID:       uuid.New().String(),
+		Response: binModels.Response{Status: 200},
+		Scenario: binModels.Scenario{
+			Mode:  binModels.ScenarioCycle,
+			Steps: binModels.Responses{{Status: 500}},
+		},
+	}
+	mockBins.On("Lookup", mock.Anything, bin.ID).Return(bin, nil)
+	mockDB.On("Create", bin.ID, mock.Anything).Return(nil)
+	mockSequence.On("Next", bin.ID).Return(int64(0), errors.New("redis unavailable"))
+
+	response, err := testHandler.Capture(context.Background(), bin.ID, &models.Request{})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+}
+
+func TestCaptureTemplate(t *testing.T) {
+	testHandlerSetup()
+
+	bin := &binModels.Bin{
+		ID: uuid.New().String(),
+		Response: binModels.Response{
+			Status:   200,
+			Headers:  binModels.Headers{"X-Echo": `{{.Headers.Get "X-Delivery"}}`},
+			Body:     `{{.JSON.challenge}}`,
+			Template: true,
+		},
+	}
+	mockBins.On("Lookup", mock.Anything, bin.ID).Return(bin, nil)
+	mockDB.On("Create", bin.ID, mock.Anything).Return(nil)
+
+	response, err := testHandler.Capture(context.Background(), bin.ID, &models.Request{
+		Headers: models.Headers{"X-Delivery": []string{"42"}},
+		Body:    []byte(`{"challenge":"abc"}`),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", response.Body)
+	assert.Equal(t, "42", response.Headers["X-Echo"])
+	assert.Equal(t, `{{.JSON.challenge}}`, bin.Response.Body, "Bin response was modified")
+
+	bin.Response.Body = `{{.JSON.challenge`
+	response, err = testHandler.Capture(context.Background(), bin.ID, &models.Request{})
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.Status)
+}
+
+func TestCaptureVerification(t *testing.T) {
+	testHandlerSetup()
+
+	bin := &binModels.Bin{
+		ID:           uuid.New().String(),
+		Response:     binModels.Response{Status: 200},
+		Verification: binModels.Verification{Provider: "github", Secret: "It's a Secret to Everybody", Reject: true},
+	}
+	mockBins.On("Lookup", mock.Anything, bin.ID).Return(bin, nil)
+	mockDB.On("Create", bin.ID, mock.Anything).Return(nil)
+
+	signed := &models.Request{
+		Headers: models.Headers{"X-Hub-Signature-256": []string{"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"}},
+		Body:    []byte("Hello, World!"),
+	}
+	response, err := testHandler.Capture(context.Background(), bin.ID, signed)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, models.VerdictVerified, signed.Verdict)
+	<-testStream.live
+
+	unsigned := &models.Request{Body: []byte("Hello, World!")}
+	response, err = testHandler.Capture(context.Background(), bin.ID, unsigned)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.Status)
+	assert.Equal(t, models.VerdictMissing, unsigned.Verdict)
+	assert.Equal(t, "signature missing", unsigned.Reason)
+	mockDB.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestCaptureMissingBin(t *testing.T) {
+	testHandlerSetup()
+
+	binID := uuid.New().String()
+	mockBins.On("Lookup", mock.Anything, binID).Return(nil, bins.ErrNotFound)
+
+	response, err := testHandler.Capture(context.Background(), binID, &models.Request{})
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+	mockDB.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestTailLive(t *testing.T) {
+	testHandlerSetup()
+
+	binID := uuid.New().String()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tail, err := testHandler.Tail(ctx, binID, "")
+	assert.Nil(t, err)
+	mockDB.AssertNotCalled(t, "GetSince")
+
+	request := &models.Request{ID: uuid.New().String(), BinID: binID}
+	testStream.Publish(request)
+	assert.Equal(t, request.ID, testReceive(t, tail).ID)
+
+	cancel()
+	_, open := <-tail
+	assert.False(t, open, "Tail was not closed")
+}
+
+func TestTailResume(t *testing.T) {
+	testHandlerSetup()
+
+	binID := uuid.New().String()
+	lastID := uuid.New().String()
+	backlog := []*models.Request{
+		{ID: uuid.New().String(), BinID: binID},
+		{ID: uuid.New().String(), BinID: binID},
+	}
+	mockDB.On("GetSince", binID, lastID, 100).Return(backlog, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// published while the backlog was read, must not be delivered twice
+	testStream.Publish(backlog[1])
+	live := &models.Request{ID: uuid.New().String(), BinID: binID}
+	testStream.Publish(live)
+
+	tail, err := testHandler.Tail(ctx, binID, lastID)
+	assert.Nil(t, err)
+
+	assert.Equal(t, backlog[0].ID, testReceive(t, tail).ID)
+	assert.Equal(t, backlog[1].ID, testReceive(t, tail).ID)
+	assert.Equal(t, live.ID, testReceive(t, tail).ID)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+const (
+	replayTimeout     = 30 * time.Second
+	dialTimeout       = 10 * time.Second
+	maxReplayBodySize = 1 << 20
+)
+
+var errForbiddenTarget = errors.New("replay target address is not allowed")
+
+// sharedNetwork is the carrier grade NAT range, internal to the provider
+var _, sharedNetwork, _ = net.ParseCIDR("100.64.0.0/10")
+
+// hopHeaders are connection specific and never forwarded on replay
+var hopHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Host",
+	"Keep-Alive",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// replayClient reports the target response as is instead of following
+// redirects. It only dials public addresses and the allowed networks, and
+// never goes through a proxy that would dial on its behalf.
+func replayClient(allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			return checkTarget(address, allowed)
+		},
+	}
+
+	return &http.Client{
+		Timeout: replayTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: dialTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkTarget refuses the loopback, private, link local, shared and
+// unspecified addresses outside of the allowed networks. It runs on the
+// resolved address, so that names resolving to internal addresses are
+// refused as well.
+func checkTarget(address string, allowed []*net.IPNet) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", errForbiddenTarget, host)
+	}
+
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || sharedNetwork.Contains(ip) {
+		return fmt.Errorf("%w: %s", errForbiddenTarget, ip)
+	}
+
+	return nil
+}
+
+// Replay re-sends the captured request to options.URL with its original
+// method, headers and body unless overridden. The attempt is recorded even
+// when the target cannot be reached or is not allowed. A nil replay is
+// returned when the bin has no such request. The replay stops once ctx is done.
+func (h *Handler) Replay(ctx context.Context, binID string, ID string, options *models.ReplayOptions) (*models.Replay, error) {
+	request, err := h.repo.DB.Get(binID, ID)
+	if err != nil || request == nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for key, values := range request.Headers {
+		headers[key] = append([]string{}, values...)
+	}
+	for _, key := range hopHeaders {
+		headers.Del(key)
+	}
+	for key, value := range options.Headers {
+		headers.Set(key, value)
+	}
+
+	body := request.Body
+	if options.Body != nil {
+		body = []byte(*options.Body)
+	}
+
+	replay := &models.Replay{
+		URL:            target.String(),
+		Method:         request.Method,
+		RequestHeaders: models.Headers(headers),
+		RequestBody:    body,
+	}
+	h.send(ctx, replay)
+
+	if err := h.repo.Replays.Create(request.ID, replay); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// GetReplays returns the replay attempts of the captured request
+func (h *Handler) GetReplays(ID string) ([]*models.Replay, error) {
+	return h.repo.Replays.GetAll(ID)
+}
+
+// send issues the replay and records the response or the failure reason
+func (h *Handler) send(ctx context.Context, replay *models.Replay) {
+	req, err := http.NewRequestWithContext(ctx, replay.Method, replay.URL, bytes.NewReader(replay.RequestBody))
+	if err != nil {
+		replay.Error = err.Error()
+		return
+	}
+	req.Header = http.Header(replay.RequestHeaders).Clone()
+
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	if err != nil {
+		replay.Latency = time.Since(start).Milliseconds()
+		replay.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxReplayBodySize))
+	replay.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		replay.Error = err.Error()
+	}
+
+	replay.StatusCode = resp.StatusCode
+	replay.ResponseHeaders = models.Headers(resp.Header)
+	replay.ResponseBody = body
+}
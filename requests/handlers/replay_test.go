@@ -0,0 +1,187 @@
+package handlers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/bins"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/handlers"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testCapturedRequest(binID string) *models.Request {
+	return &models.Request{
+		ID:     uuid.New().String(),
+		BinID:  binID,
+		Method: "PUT",
+		Path:   "/hook",
+		Headers: models.Headers{
+			"Content-Type":   []string{"application/json"},
+			"X-Signature":    []string{"original"},
+			"Content-Length": []string{"13"},
+		},
+		Body: []byte(`{"ok":"yes"}`),
+	}
+}
+
+func TestReplay(t *testing.T) {
+	testHandlerSetup()
+
+	var received *http.Request
+	var receivedBody []byte
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Target", "staging")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer target.Close()
+
+	binID := uuid.New().String()
+	request := testCapturedRequest(binID)
+	mockDB.On("Get", binID, request.ID).Return(request, nil)
+	mockReplays.On("Create", request.ID, mock.Anything).Return(nil)
+
+	replay, err := testHandler.Replay(context.Background(), binID, request.ID, &models.ReplayOptions{URL: target.URL + "/hook"})
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT", received.Method)
+	assert.Equal(t, "/hook", received.URL.Path)
+	assert.Equal(t, "original", received.Header.Get("X-Signature"))
+	assert.Equal(t, request.Body, receivedBody)
+
+	assert.Equal(t, http.StatusAccepted, replay.StatusCode)
+	assert.Equal(t, "staging", http.Header(replay.ResponseHeaders).Get("X-Target"))
+	assert.Equal(t, []byte("queued"), replay.ResponseBody)
+	assert.Equal(t, "", replay.Error)
+	mockReplays.AssertExpectations(t)
+}
+
+func TestReplayOverrides(t *testing.T) {
+	testHandlerSetup()
+
+	var received *http.Request
+	var receivedBody []byte
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer target.Close()
+
+	binID := uuid.New().String()
+	request := testCapturedRequest(binID)
+	mockDB.On("Get", binID, request.ID).Return(request, nil)
+	mockReplays.On("Create", request.ID, mock.Anything).Return(nil)
+
+	body := `{"ok":"no"}`
+	_, err := testHandler.Replay(context.Background(), binID, request.ID, &models.ReplayOptions{
+		URL:     target.URL,
+		Headers: map[string]string{"X-Signature": "fixed"},
+		Body:    &body,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "fixed", received.Header.Get("X-Signature"))
+	assert.Equal(t, body, string(receivedBody))
+	assert.Equal(t, []string{"original"}, request.Headers["X-Signature"], "Stored request was modified")
+}
+
+func TestReplayUnreachable(t *testing.T) {
+	testHandlerSetup()
+
+	target := httptest.NewServer(http.NotFoundHandler())
+	target.Close()
+
+	binID := uuid.New().String()
+	request := testCapturedRequest(binID)
+	mockDB.On("Get", binID, request.ID).Return(request, nil)
+	mockReplays.On("Create", request.ID, mock.Anything).Return(nil)
+
+	replay, err := testHandler.Replay(context.Background(), binID, request.ID, &models.ReplayOptions{URL: target.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, replay.StatusCode)
+	assert.NotEqual(t, "", replay.Error, "Expected the failure to be recorded")
+}
+
+func TestReplayMissingRequest(t *testing.T) {
+	testHandlerSetup()
+
+	binID := uuid.New().String()
+	ID := uuid.New().String()
+	mockDB.On("Get", binID, ID).Return(nil, nil)
+
+	replay, err := testHandler.Replay(context.Background(), binID, ID, &models.ReplayOptions{URL: "http://localhost"})
+	assert.Nil(t, err)
+	assert.Nil(t, replay)
+	mockReplays.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReplayForbiddenTargets(t *testing.T) {
+	testHandlerSetup()
+
+	hit := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer target.Close()
+
+	binID := uuid.New().String()
+	request := testCapturedRequest(binID)
+	mockDB.On("Get", binID, request.ID).Return(request, nil)
+	mockReplays.On("Create", request.ID, mock.Anything).Return(nil)
+
+	// no network is allowed besides the public addresses
+	handler := handlers.New(
+		&requests.Repository{DB: mockDB, Replays: mockReplays, Stream: testStream},
+		&bins.Repository{DB: mockBins, Sequence: mockSequence},
+		nil,
+	)
+
+	for _, URL := range []string{
+		target.URL,
+		strings.Replace(target.URL, "127.0.0.1", "localhost", 1),
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::1]:8080/",
+		"http://0.0.0.0:8080/",
+	} {
+		replay, err := handler.Replay(context.Background(), binID, request.ID, &models.ReplayOptions{URL: URL})
+		assert.Nil(t, err, URL)
+		assert.Equal(t, 0, replay.StatusCode, URL)
+		assert.Contains(t, replay.Error, "not allowed", URL)
+	}
+	assert.False(t, hit, "Expected the loopback target to be refused")
+}
+
+func TestReplayCanceled(t *testing.T) {
+	testHandlerSetup()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server notices the client left once the body is read
+		ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+	}))
+	defer target.Close()
+
+	binID := uuid.New().String()
+	request := testCapturedRequest(binID)
+	mockDB.On("Get", binID, request.ID).Return(request, nil)
+	mockReplays.On("Create", request.ID, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	replay, err := testHandler.Replay(ctx, binID, request.ID, &models.ReplayOptions{URL: target.URL})
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", replay.Error, "Expected the cancellation to be recorded")
+	assert.True(t, time.Since(start) < 5*time.Second, "Expected the replay to stop with the caller")
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/template"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// render returns a copy of the response with its body and headers rendered
+// against the captured request. Template errors answer with a 500 explaining
+// the failure so it shows up in the provider delivery logs.
+func render(ctx context.Context, response *binModels.Response, request *models.Request) *binModels.Response {
+	if !response.Template {
+		return response
+	}
+
+	data := template.NewData(request.Method, request.Path, request.Query, http.Header(request.Headers).Clone(), request.Body, request.RemoteIP)
+
+	rendered := *response
+	rendered.Headers = binModels.Headers{}
+
+	var err error
+	if rendered.Body, err = template.Render(response.Body, data); err != nil {
+		return templateError(ctx, err)
+	}
+	for key, value := range response.Headers {
+		if rendered.Headers[key], err = template.Render(value, data); err != nil {
+			return templateError(ctx, err)
+		}
+	}
+
+	return &rendered
+}
+
+func templateError(ctx context.Context, err error) *binModels.Response {
+	logging.FromContext(ctx).Warn("failed to render response template: ", err)
+
+	return &binModels.Response{
+		Status:      http.StatusInternalServerError,
+		Body:        "template error: " + err.Error(),
+		ContentType: "text/plain; charset=utf-8",
+	}
+}
@@ -0,0 +1,63 @@
+// Package handshakes answers the verification requests webhook providers send
+// before delivering events
+package handshakes
+
+import (
+	"context"
+	"sort"
+
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// Handshake answers the verification requests of one provider
+type Handshake interface {
+	// Answer returns the response to the request and true when the request is
+	// a verification request of the provider. Calls to the provider end with
+	// ctx.
+	Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool)
+}
+
+// Signed is implemented by the handshakes signing their answer with the
+// secret of the bin, which they cannot answer without
+type Signed interface {
+	RequiresSecret() bool
+}
+
+// providers answers the verification requests of every supported provider
+var providers = map[string]Handshake{
+	"slack":   &Slack{},
+	"twitter": &Twitter{},
+	"meta":    &Meta{},
+	"graph":   &Graph{},
+	"sns":     NewSNS(nil),
+	"zoom":    &Zoom{},
+}
+
+// Get returns the handshake of the provider
+func Get(name string) (Handshake, bool) {
+	handshake, ok := providers[name]
+	return handshake, ok
+}
+
+// RequiresSecret tells whether the handshake of the provider needs a secret
+func RequiresSecret(name string) bool {
+	handshake, ok := Get(name)
+	if !ok {
+		return false
+	}
+
+	signed, ok := handshake.(Signed)
+	return ok && signed.RequiresSecret()
+}
+
+// Names returns the providers in order
+func Names() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,222 @@
+package handshakes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hugocortes/hooks-api/requests/handshakes"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	snsConfirmation = `{
+  "Type" : "SubscriptionConfirmation",
+  "MessageId" : "165545c9-2a5c-472c-8df2-7ff2be2b3b1b",
+  "Token" : "2336412f37fb687f5d51e6e241d09c805a5a57b30d712f794cc5f6a988666d92768dd60a747ba6f3beb71854e285d6ad02428b09ceece29417f1f02d609c582afbacc99c583a916b9981dd2728f4ae6fdb82efd087cc3b7849e05798d2d2785c03b0879594eeac82c01f235d0e717736",
+  "TopicArn" : "arn:aws:sns:us-west-2:123456789012:MyTopic",
+  "Message" : "You have chosen to subscribe to the topic arn:aws:sns:us-west-2:123456789012:MyTopic.",
+  "SubscribeURL" : "https://sns.us-west-2.amazonaws.com/?Action=ConfirmSubscription&TopicArn=arn:aws:sns:us-west-2:123456789012:MyTopic&Token=2336412f37",
+  "Timestamp" : "2012-04-26T20:45:04.751Z",
+  "SignatureVersion" : "1"
+}`
+	snsNotification = `{"Type":"Notification","MessageId":"22b80b92","Message":"Hello world!"}`
+)
+
+type answer struct {
+	handled     bool
+	status      int
+	body        string
+	contentType string
+}
+
+func TestHandshakes(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		request  *models.Request
+		expected answer
+	}{
+		{
+			name:     "slack url verification",
+			provider: "slack",
+			request: &models.Request{
+				Method: "POST",
+				Body:   []byte(`{"token":"Jhj5dZrVaK7ZwHHjRyZWjbDl","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`),
+			},
+			expected: answer{true, 200, "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "slack event",
+			provider: "slack",
+			request: &models.Request{
+				Method: "POST",
+				Body:   []byte(`{"type":"event_callback","event":{"type":"app_mention"}}`),
+			},
+			expected: answer{handled: false},
+		},
+		{
+			name:     "twitter crc",
+			provider: "twitter",
+			secret:   "consumer-secret",
+			request:  &models.Request{Method: "GET", Query: "crc_token=crc-8f4f1c&nonce=MTUyNjA3"},
+			expected: answer{true, 200, `{"response_token":"sha256=zOJ8spRoFLxDWdQ5aMnigvsnBOP9jc68PG+zW+esHnk="}`, "application/json"},
+		},
+		{
+			name:     "twitter event",
+			provider: "twitter",
+			secret:   "consumer-secret",
+			request:  &models.Request{Method: "POST", Body: []byte(`{"for_user_id":"2244994945"}`)},
+			expected: answer{handled: false},
+		},
+		{
+			name:     "twitter crc without secret",
+			provider: "twitter",
+			request:  &models.Request{Method: "GET", Query: "crc_token=crc-8f4f1c&nonce=MTUyNjA3"},
+			expected: answer{true, 400, "handshake secret is not set", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "meta hub challenge",
+			provider: "meta",
+			secret:   "meatyhamhock",
+			request:  &models.Request{Method: "GET", Query: "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=meatyhamhock"},
+			expected: answer{true, 200, "1158201444", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "meta verify token mismatch",
+			provider: "meta",
+			secret:   "meatyhamhock",
+			request:  &models.Request{Method: "GET", Query: "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=other"},
+			expected: answer{true, 403, "verify token mismatch", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "meta without verify token",
+			provider: "meta",
+			request:  &models.Request{Method: "GET", Query: "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=any"},
+			expected: answer{true, 200, "1158201444", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "graph validation token",
+			provider: "graph",
+			request:  &models.Request{Method: "POST", Query: "validationToken=Validation%3a+Testing+client+application+reachability+for+subscription+Request-Id%3a+9a3d6e8b"},
+			expected: answer{true, 200, "Validation: Testing client application reachability for subscription Request-Id: 9a3d6e8b", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "graph notification",
+			provider: "graph",
+			request:  &models.Request{Method: "POST", Body: []byte(`{"value":[{"changeType":"created"}]}`)},
+			expected: answer{handled: false},
+		},
+		{
+			name:     "sns subscription confirmation",
+			provider: "sns",
+			request: &models.Request{
+				Method:  "POST",
+				Headers: models.Headers{"X-Amz-Sns-Message-Type": []string{"SubscriptionConfirmation"}},
+				Body:    []byte(snsConfirmation),
+			},
+			expected: answer{true, 200, "subscription confirmed", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "sns notification",
+			provider: "sns",
+			request: &models.Request{
+				Method:  "POST",
+				Headers: models.Headers{"X-Amz-Sns-Message-Type": []string{"Notification"}},
+				Body:    []byte(snsNotification),
+			},
+			expected: answer{handled: false},
+		},
+		{
+			name:     "zoom url validation",
+			provider: "zoom",
+			secret:   "zoom-secret-token",
+			request: &models.Request{
+				Method: "POST",
+				Body:   []byte(`{"payload":{"plainToken":"qgg8vlvZRS6UYooatFL8Aw"},"event_ts":1654503849680,"event":"endpoint.url_validation"}`),
+			},
+			expected: answer{true, 200, `{"encryptedToken":"d9e0d764a78494688ba3f2d5c0ed4ca311394b4834d959759213424d2df961e3","plainToken":"qgg8vlvZRS6UYooatFL8Aw"}`, "application/json"},
+		},
+		{
+			name:     "zoom url validation without secret",
+			provider: "zoom",
+			request: &models.Request{
+				Method: "POST",
+				Body:   []byte(`{"payload":{"plainToken":"qgg8vlvZRS6UYooatFL8Aw"},"event":"endpoint.url_validation"}`),
+			},
+			expected: answer{true, 400, "handshake secret is not set", "text/plain; charset=utf-8"},
+		},
+		{
+			name:     "zoom event",
+			provider: "zoom",
+			secret:   "zoom-secret-token",
+			request:  &models.Request{Method: "POST", Body: []byte(`{"event":"meeting.started","payload":{}}`)},
+			expected: answer{handled: false},
+		},
+	}
+
+	var confirmed []string
+	sns := handshakes.NewSNS(func(ctx context.Context, subscribeURL string) error {
+		confirmed = append(confirmed, subscribeURL)
+		return nil
+	})
+
+	for _, test := range tests {
+		handshake, ok := handshakes.Get(test.provider)
+		assert.True(t, ok, test.name)
+		if test.provider == "sns" {
+			// confirms the subscription without calling AWS
+			handshake = sns
+		}
+
+		response, handled := handshake.Answer(context.Background(), test.request, test.secret)
+		assert.Equal(t, test.expected.handled, handled, test.name)
+		if !test.expected.handled {
+			assert.Nil(t, response, test.name)
+			continue
+		}
+
+		assert.Equal(t, test.expected.status, response.Status, test.name)
+		assert.Equal(t, test.expected.body, response.Body, test.name)
+		assert.Equal(t, test.expected.contentType, response.ContentType, test.name)
+	}
+
+	assert.Equal(t, []string{"https://sns.us-west-2.amazonaws.com/?Action=ConfirmSubscription&TopicArn=arn:aws:sns:us-west-2:123456789012:MyTopic&Token=2336412f37"}, confirmed)
+}
+
+func TestSNSConfirmationFailure(t *testing.T) {
+	handshake := handshakes.NewSNS(func(context.Context, string) error { return errors.New("unreachable") })
+
+	response, handled := handshake.Answer(context.Background(), &models.Request{
+		Method:  "POST",
+		Headers: models.Headers{"X-Amz-Sns-Message-Type": []string{"SubscriptionConfirmation"}},
+		Body:    []byte(snsConfirmation),
+	}, "")
+	assert.True(t, handled)
+	assert.Equal(t, 502, response.Status)
+}
+
+func TestSNSRejectsForeignHosts(t *testing.T) {
+	handshake := handshakes.NewSNS(nil)
+
+	response, handled := handshake.Answer(context.Background(), &models.Request{
+		Method:  "POST",
+		Headers: models.Headers{"X-Amz-Sns-Message-Type": []string{"SubscriptionConfirmation"}},
+		Body:    []byte(`{"SubscribeURL":"https://sns.us-west-2.amazonaws.com.evil.test/confirm"}`),
+	}, "")
+	assert.True(t, handled)
+	assert.Equal(t, 502, response.Status)
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"graph", "meta", "slack", "sns", "twitter", "zoom"}, handshakes.Names())
+}
+
+func TestRequiresSecret(t *testing.T) {
+	assert.True(t, handshakes.RequiresSecret("twitter"))
+	assert.True(t, handshakes.RequiresSecret("zoom"))
+	assert.False(t, handshakes.RequiresSecret("meta"))
+	assert.False(t, handshakes.RequiresSecret("unknown"))
+}
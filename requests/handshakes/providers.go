@@ -0,0 +1,151 @@
+package handshakes
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// Slack echoes the challenge of url_verification events
+type Slack struct{}
+
+// Answer ...
+func (h *Slack) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	event := struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+	}{}
+	if json.Unmarshal(request.Body, &event) != nil || event.Type != "url_verification" {
+		return nil, false
+	}
+
+	return text(http.StatusOK, event.Challenge), true
+}
+
+// Twitter signs the crc_token of challenge-response checks with the consumer
+// secret
+type Twitter struct{}
+
+// Answer ...
+func (h *Twitter) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	token := query(request).Get("crc_token")
+	if request.Method != http.MethodGet || token == "" {
+		return nil, false
+	}
+	if secret == "" {
+		return missingSecret(), true
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+
+	return jsonResponse(map[string]string{
+		"response_token": "sha256=" + base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}), true
+}
+
+// RequiresSecret ...
+func (h *Twitter) RequiresSecret() bool {
+	return true
+}
+
+// Meta echoes hub.challenge of subscription verifications. When a secret is
+// set, hub.verify_token must match it.
+type Meta struct{}
+
+// Answer ...
+func (h *Meta) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	values := query(request)
+	if request.Method != http.MethodGet || values.Get("hub.mode") != "subscribe" {
+		return nil, false
+	}
+
+	if secret != "" && !hmac.Equal([]byte(values.Get("hub.verify_token")), []byte(secret)) {
+		return text(http.StatusForbidden, "verify token mismatch"), true
+	}
+
+	return text(http.StatusOK, values.Get("hub.challenge")), true
+}
+
+// Graph echoes the validationToken of Microsoft Graph subscriptions
+type Graph struct{}
+
+// Answer ...
+func (h *Graph) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	token := query(request).Get("validationToken")
+	if token == "" {
+		return nil, false
+	}
+
+	return text(http.StatusOK, token), true
+}
+
+// Zoom signs the plainToken of endpoint.url_validation events with the secret
+// token of the app
+type Zoom struct{}
+
+// Answer ...
+func (h *Zoom) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	event := struct {
+		Event   string `json:"event"`
+		Payload struct {
+			PlainToken string `json:"plainToken"`
+		} `json:"payload"`
+	}{}
+	if json.Unmarshal(request.Body, &event) != nil || event.Event != "endpoint.url_validation" {
+		return nil, false
+	}
+	if secret == "" {
+		return missingSecret(), true
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(event.Payload.PlainToken))
+
+	return jsonResponse(map[string]string{
+		"plainToken":     event.Payload.PlainToken,
+		"encryptedToken": hex.EncodeToString(mac.Sum(nil)),
+	}), true
+}
+
+// RequiresSecret ...
+func (h *Zoom) RequiresSecret() bool {
+	return true
+}
+
+// missingSecret refuses to sign the challenge with an empty key, which anyone
+// could forge
+func missingSecret() *binModels.Response {
+	return text(http.StatusBadRequest, "handshake secret is not set")
+}
+
+func query(request *models.Request) url.Values {
+	values, _ := url.ParseQuery(request.Query)
+	return values
+}
+
+func text(status int, body string) *binModels.Response {
+	return &binModels.Response{
+		Status:      status,
+		Body:        body,
+		ContentType: "text/plain; charset=utf-8",
+	}
+}
+
+func jsonResponse(body interface{}) *binModels.Response {
+	marshalled, _ := json.Marshal(body)
+
+	return &binModels.Response{
+		Status:      http.StatusOK,
+		Body:        string(marshalled),
+		ContentType: "application/json",
+	}
+}
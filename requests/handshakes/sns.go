@@ -0,0 +1,89 @@
+package handshakes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/tracing"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+const (
+	confirmTimeout = 10 * time.Second
+)
+
+// snsHost only lets the subscription be confirmed against AWS endpoints
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsClient visits the SubscribeURLs
+var snsClient = tracing.Client(confirmTimeout)
+
+// SNS confirms SubscriptionConfirmation messages by visiting their SubscribeURL
+type SNS struct {
+	confirm func(ctx context.Context, subscribeURL string) error
+}
+
+// NewSNS returns the SNS handshake. A nil confirm visits the SubscribeURL over
+// https once its host is verified to belong to AWS.
+func NewSNS(confirm func(ctx context.Context, subscribeURL string) error) *SNS {
+	if confirm == nil {
+		confirm = visit
+	}
+	return &SNS{confirm: confirm}
+}
+
+// Answer ...
+func (h *SNS) Answer(ctx context.Context, request *models.Request, secret string) (*binModels.Response, bool) {
+	if http.Header(request.Headers).Get("X-Amz-Sns-Message-Type") != "SubscriptionConfirmation" {
+		return nil, false
+	}
+
+	message := struct {
+		SubscribeURL string `json:"SubscribeURL"`
+	}{}
+	if err := json.Unmarshal(request.Body, &message); err != nil || message.SubscribeURL == "" {
+		return text(http.StatusBadRequest, "missing SubscribeURL"), true
+	}
+
+	if err := h.confirm(ctx, message.SubscribeURL); err != nil {
+		logging.FromContext(ctx).Warn("failed to confirm sns subscription: ", err)
+		return text(http.StatusBadGateway, "subscription confirmation failed"), true
+	}
+
+	return text(http.StatusOK, "subscription confirmed"), true
+}
+
+// visit confirms the subscription, traced and cancelled with ctx
+func visit(ctx context.Context, subscribeURL string) error {
+	target, err := url.Parse(subscribeURL)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "https" || !snsHost.MatchString(target.Hostname()) {
+		return errors.New("SubscribeURL is not an AWS SNS endpoint")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := snsClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("SubscribeURL answered %d", resp.StatusCode)
+	}
+
+	return nil
+}
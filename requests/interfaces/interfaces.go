@@ -0,0 +1,291 @@
+package interfaces
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/middleware"
+	"github.com/hugocortes/hooks-api/common/problem"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+const (
+	maxBodySize = 1 << 20
+)
+
+// Interface exposes the captured request handler over http
+type Interface struct {
+	handler  requests.Handler
+	upgrader websocket.Upgrader
+	proxies  []*net.IPNet
+
+	stopping chan struct{}
+	stop     sync.Once
+}
+
+// replayBody is the accepted payload when replaying a captured request
+type replayBody struct {
+	URL     string            `json:"url" binding:"required"`
+	Headers map[string]string `json:"headers"`
+	Body    *string           `json:"body"`
+}
+
+// New ...
+func New(handler requests.Handler, origins []string, proxies []*net.IPNet) *Interface {
+	return &Interface{
+		handler:  handler,
+		upgrader: websocket.Upgrader{CheckOrigin: checkOrigin(origins)},
+		proxies:  proxies,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Stop ends the open streams so that the server can drain, their clients
+// reconnect to another instance
+func (i *Interface) Stop() {
+	i.stop.Do(func() {
+		close(i.stopping)
+	})
+}
+
+// AddRoutes registers the public capture routes on router and the routes to
+// read captured requests on authenticated
+func (i *Interface) AddRoutes(router gin.IRouter, authenticated gin.IRouter) {
+	binID := logging.Param("bin_id", "binID")
+	router.Any("/b/:binID", binID, i.capture)
+	router.Any("/b/:binID/*path", binID, i.capture)
+
+	group := authenticated.Group("/bins/:id/requests", logging.Param("bin_id", "id"), i.owned)
+	group.GET("", i.getAll)
+	group.GET("/stream", i.sse)
+	group.GET("/ws", i.websocket)
+	group.GET("/:requestID", i.get)
+	group.GET("/:requestID/replays", i.getReplays)
+	group.POST("/:requestID/replays", i.replay)
+}
+
+// scheme returns the scheme the client used, as forwarded by a trusted proxy
+func (i *Interface) scheme(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	proto := c.GetHeader("X-Forwarded-Proto")
+	if proto != "http" && proto != "https" {
+		return scheme
+	}
+	remote := net.ParseIP(c.RemoteIP())
+	for _, proxy := range i.proxies {
+		if proxy.Contains(remote) {
+			return proto
+		}
+	}
+
+	return scheme
+}
+
+func (i *Interface) capture(c *gin.Context) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+	if err != nil {
+		problem.Abort(c, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	path := c.Param("path")
+	if path == "" {
+		path = "/"
+	}
+
+	request := &models.Request{
+		Method:   c.Request.Method,
+		URL:      i.scheme(c) + "://" + c.Request.Host + c.Request.URL.RequestURI(),
+		Path:     path,
+		Query:    c.Request.URL.RawQuery,
+		Headers:  models.Headers(c.Request.Header.Clone()),
+		Body:     body,
+		RemoteIP: c.ClientIP(),
+	}
+
+	response, err := i.handler.Capture(c.Request.Context(), c.Param("binID"), request)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if response == nil {
+		problem.Abort(c, http.StatusNotFound, "Bin not found")
+		return
+	}
+
+	c.Header("X-Hooks-Request-ID", request.ID)
+	respond(c, response)
+}
+
+// respond writes the canned response of the bin after its delay. The delay is
+// cut short when the client goes away. Timeouts hold the request for the
+// delay, or the longest delay allowed, then drop the connection unanswered.
+func respond(c *gin.Context, response *binModels.Response) {
+	delay := time.Duration(response.Delay) * time.Millisecond
+	if response.Timeout && delay == 0 {
+		delay = binModels.MaxDelay * time.Millisecond
+	}
+
+	if delay > 0 {
+		if delay > binModels.MaxDelay*time.Millisecond {
+			delay = binModels.MaxDelay * time.Millisecond
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+			c.Abort()
+			return
+		}
+	}
+
+	if response.Timeout {
+		drop(c)
+		return
+	}
+
+	for key, value := range response.Headers {
+		c.Header(key, value)
+	}
+
+	contentType := response.ContentType
+	if contentType == "" {
+		contentType = c.Writer.Header().Get("Content-Type")
+	}
+	if contentType == "" && response.Body != "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	if contentType == "" {
+		c.Status(response.StatusCode())
+		return
+	}
+
+	c.Data(response.StatusCode(), contentType, []byte(response.Body))
+}
+
+// owned rejects calls for bins the account does not own
+func (i *Interface) owned(c *gin.Context) {
+	owned, err := i.handler.Owns(c.Request.Context(), middleware.AccountID(c), c.Param("id"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if !owned {
+		problem.Abort(c, http.StatusNotFound, "Bin not found")
+		return
+	}
+
+	c.Next()
+}
+
+func (i *Interface) getAll(c *gin.Context) {
+	opts, err := gModels.ParseQueryOpts(c.Query("page"), c.Query("limit"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	requests, err := i.handler.GetAll(c.Param("id"), opts)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if requests == nil {
+		requests = []*models.Request{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"page": opts.Page, "limit": opts.GetLimit(), "requests": requests})
+}
+
+func (i *Interface) get(c *gin.Context) {
+	request, err := i.handler.Get(c.Param("id"), c.Param("requestID"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if request == nil {
+		problem.Abort(c, http.StatusNotFound, "Request not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+func (i *Interface) getReplays(c *gin.Context) {
+	request, err := i.handler.Get(c.Param("id"), c.Param("requestID"))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if request == nil {
+		problem.Abort(c, http.StatusNotFound, "Request not found")
+		return
+	}
+
+	replays, err := i.handler.GetReplays(request.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if replays == nil {
+		replays = []*models.Replay{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replays": replays})
+}
+
+func (i *Interface) replay(c *gin.Context) {
+	body := &replayBody{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if target, err := url.Parse(body.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		problem.Abort(c, http.StatusBadRequest, "url must be an absolute http(s) url")
+		return
+	}
+
+	replay, err := i.handler.Replay(c.Request.Context(), c.Param("id"), c.Param("requestID"), &models.ReplayOptions{
+		URL:     body.URL,
+		Headers: body.Headers,
+		Body:    body.Body,
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if replay == nil {
+		problem.Abort(c, http.StatusNotFound, "Request not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, replay)
+}
+
+// drop closes the client connection without writing a response
+func drop(c *gin.Context) {
+	c.Abort()
+
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		c.Status(http.StatusGatewayTimeout)
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,210 @@
+package interfaces_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	binModels "github.com/hugocortes/hooks-api/bins/models"
+	"github.com/hugocortes/hooks-api/requests/interfaces"
+	"github.com/hugocortes/hooks-api/requests/mocks"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var router *gin.Engine
+var mockHandler *mocks.Handler
+
+func testInterfaceSetup() {
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	mockHandler = new(mocks.Handler)
+	interfaces.New(mockHandler, nil, nil).AddRoutes(router, router.Group("/authenticated"))
+}
+
+func testCapture(method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCaptureRequest(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	var captured *models.Request
+	mockHandler.On("Capture", mock.Anything, binID, mock.Anything).Return(&binModels.Response{}, nil).Run(func(args mock.Arguments) {
+		captured = args.Get(2).(*models.Request)
+		captured.ID = uuid.New().String()
+	})
+
+	w := testCapture("POST", "/b/"+binID+"/github/push?delivery=1", `{"ref":"main"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, captured.ID, w.Header().Get("X-Hooks-Request-ID"))
+	assert.Equal(t, "POST", captured.Method)
+	assert.Equal(t, "/github/push", captured.Path)
+	assert.Equal(t, "delivery=1", captured.Query)
+	assert.Equal(t, `{"ref":"main"}`, string(captured.Body))
+	assert.Equal(t, "application/json", captured.Headers["Content-Type"][0])
+
+	w = testCapture("GET", "/b/"+binID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/", captured.Path)
+}
+
+func TestCaptureForwardedProto(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	mockHandler = new(mocks.Handler)
+	_, proxies, _ := net.ParseCIDR("192.0.2.0/24")
+	interfaces.New(mockHandler, nil, []*net.IPNet{proxies}).AddRoutes(router, router.Group("/authenticated"))
+
+	binID := uuid.New().String()
+	var captured *models.Request
+	mockHandler.On("Capture", mock.Anything, binID, mock.Anything).Return(&binModels.Response{}, nil).Run(func(args mock.Arguments) {
+		captured = args.Get(2).(*models.Request)
+	})
+
+	tests := map[string]struct {
+		remote   string
+		proto    string
+		expected string
+	}{
+		"trusted proxy":   {remote: "192.0.2.1:4000", proto: "https", expected: "https://"},
+		"untrusted proxy": {remote: "198.51.100.1:4000", proto: "https", expected: "http://"},
+		"unknown scheme":  {remote: "192.0.2.1:4000", proto: "javascript", expected: "http://"},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest("GET", "/b/"+binID, nil)
+		req.RemoteAddr = test.remote
+		req.Header.Set("X-Forwarded-Proto", test.proto)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.True(t, strings.HasPrefix(captured.URL, test.expected), name)
+	}
+}
+
+func TestCaptureMissingBin(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	mockHandler.On("Capture", mock.Anything, binID, mock.Anything).Return(nil, nil)
+
+	w := testCapture("POST", "/b/"+binID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCaptureCannedResponse(t *testing.T) {
+	testInterfaceSetup()
+
+	binID := uuid.New().String()
+	response := &binModels.Response{
+		Status:      http.StatusGone,
+		Headers:     binModels.Headers{"X-Unsubscribe": "true"},
+		Body:        `{"unsubscribed":true}`,
+		ContentType: "application/json",
+		Delay:       50,
+	}
+	mockHandler.On("Capture", mock.Anything, binID, mock.Anything).Return(response, nil)
+
+	start := time.Now()
+	w := testCapture("POST", "/b/"+binID, "")
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "Delay was not applied")
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Unsubscribe"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"unsubscribed":true}`, w.Body.String())
+}
+
+func TestCaptureTimeout(t *testing.T) {
+	testInterfaceSetup()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	binID := uuid.New().String()
+	mockHandler.On("Capture", mock.Anything, binID, mock.Anything).Return(&binModels.Response{Timeout: true, Delay: 10}, nil)
+
+	resp, err := http.Post(server.URL+"/b/"+binID, "application/json", strings.NewReader("{}"))
+	assert.NotNil(t, err, "Expected the connection to be dropped")
+	assert.Nil(t, resp)
+}
+
+func TestStreamStop(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	mockHandler = new(mocks.Handler)
+	inter := interfaces.New(mockHandler, nil, nil)
+	inter.AddRoutes(router, router.Group("/authenticated"))
+
+	binID := uuid.New().String()
+	tail := make(chan *models.Request)
+	mockHandler.On("Owns", mock.Anything, mock.Anything, binID).Return(true, nil)
+	mockHandler.On("Tail", mock.Anything, binID, "").Return((<-chan *models.Request)(tail), nil)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- testCapture("GET", "/authenticated/bins/"+binID+"/requests/stream", "")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	inter.Stop()
+	inter.Stop()
+
+	select {
+	case w := <-done:
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end on stop")
+	}
+}
+
+func TestStreamOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	mockHandler = new(mocks.Handler)
+	inter := interfaces.New(mockHandler, []string{"https://app.example.com"}, nil)
+	inter.AddRoutes(router, router.Group("/authenticated"))
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer inter.Stop()
+
+	binID := uuid.New().String()
+	mockHandler.On("Owns", mock.Anything, mock.Anything, binID).Return(true, nil)
+	mockHandler.On("Tail", mock.Anything, binID, "").Return((<-chan *models.Request)(make(chan *models.Request)), nil)
+
+	target := "ws" + strings.TrimPrefix(server.URL, "http") + "/authenticated/bins/" + binID + "/requests/ws"
+	for origin, allowed := range map[string]bool{
+		"":                        true,
+		server.URL:                true,
+		"https://app.example.com": true,
+		"https://evil.example":    false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+
+		conn, resp, err := websocket.DefaultDialer.Dial(target, header)
+		if !allowed {
+			assert.NotNil(t, err, "Expected origin %s to be refused", origin)
+			if assert.NotNil(t, resp) {
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			}
+			continue
+		}
+		if assert.Nil(t, err, "Expected origin %s to be accepted", origin) {
+			conn.Close()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hugocortes/hooks-api/common/logging"
+	"github.com/hugocortes/hooks-api/common/problem"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// checkOrigin accepts the browsers of the server's own origin and of the
+// allowed origins. The cors configuration allows every origin, so it does not
+// protect the websockets. Clients sending no Origin are not browsers.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, o := range allowed {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// lastEventID reads the resume point sent by EventSource or the query string
+func lastEventID(c *gin.Context) string {
+	if ID := c.GetHeader("Last-Event-ID"); ID != "" {
+		return ID
+	}
+	return c.Query("last_event_id")
+}
+
+// sse streams captured requests as server-sent events
+func (i *Interface) sse(c *gin.Context) {
+	tail, err := i.handler.Tail(c.Request.Context(), c.Param("id"), lastEventID(c))
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-i.stopping:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case request, ok := <-tail:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(request)
+			if err != nil {
+				logging.FromContext(c.Request.Context()).Error(err)
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: request\ndata: %s\n\n", request.ID, data)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// websocket streams captured requests as json text messages
+func (i *Interface) websocket(c *gin.Context) {
+	conn, err := i.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		return
+	}
+	defer conn.Close()
+
+	// hijacked connections do not cancel the request context when they close
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	tail, err := i.handler.Tail(ctx, c.Param("id"), lastEventID(c))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error(err)
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Internal error"))
+		return
+	}
+
+	// the client never sends data, reading only detects the connection closing
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-i.stopping:
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "Server shutting down"), time.Now().Add(writeTimeout))
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case request, ok := <-tail:
+			if !ok {
+				return
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(request); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// VerdictVerified marks a request whose signature is valid
+	VerdictVerified = "verified"
+	// VerdictFailed marks a request whose signature is invalid
+	VerdictFailed = "failed"
+	// VerdictMissing marks a request without any signature
+	VerdictMissing = "missing"
+)
+
+// Request represents an incoming webhook payload captured by a bin
+type Request struct {
+	ID        string     `gorm:"primary_key;type:char(36)" json:"id"`
+	BinID     string     `gorm:"type:char(36);not null;index:idx_bin_id" json:"bin_id"`
+	Method    string     `gorm:"size:16;not null" json:"method"`
+	URL       string     `gorm:"type:text" json:"url"`
+	Path      string     `gorm:"type:text" json:"path"`
+	Query     string     `gorm:"type:text" json:"query"`
+	Headers   Headers    `gorm:"type:text" json:"headers"`
+	Body      []byte     `json:"body"`
+	RemoteIP  string     `gorm:"size:45" json:"remote_ip"`
+	Verdict   string     `gorm:"size:16" json:"verdict,omitempty"`
+	Reason    string     `gorm:"type:text" json:"verdict_reason,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// Headers stores the captured http headers as json
+type Headers http.Header
+
+// Value implements driver.Valuer
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+
+	marshalled, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshalled), nil
+}
+
+// Scan implements sql.Scanner
+func (h *Headers) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*h = Headers{}
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return errors.New("unsupported headers value")
+	}
+
+	return json.Unmarshal(raw, h)
+}
+
+// Replay records the outcome of re-sending a captured request to a target
+type Replay struct {
+	ID              string     `gorm:"primary_key;type:char(36)" json:"id"`
+	RequestID       string     `gorm:"type:char(36);not null;index:idx_request_id" json:"request_id"`
+	URL             string     `gorm:"type:text;not null" json:"url"`
+	Method          string     `gorm:"size:16;not null" json:"method"`
+	RequestHeaders  Headers    `gorm:"type:text" json:"request_headers"`
+	RequestBody     []byte     `json:"request_body"`
+	StatusCode      int        `json:"status_code"`
+	ResponseHeaders Headers    `gorm:"type:text" json:"response_headers"`
+	ResponseBody    []byte     `json:"response_body"`
+	Latency         int64      `json:"latency_ms"`
+	Error           string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt       *time.Time `json:"created_at"`
+}
+
+// ReplayOptions overrides parts of the captured request when replaying it
+type ReplayOptions struct {
+	URL     string
+	Headers map[string]string
+	Body    *string
+}
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"context"
+
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// Repository ...
+type Repository struct {
+	DB      DB
+	Replays ReplayDB
+	Stream  Stream
+}
+
+// DB ...
+type DB interface {
+	GetAll(binID string, opts *gModels.QueryOpts) ([]*models.Request, error)
+	GetSince(binID string, ID string, limit int) ([]*models.Request, error)
+	Get(binID string, ID string) (*models.Request, error)
+	Create(binID string, request *models.Request) error
+}
+
+// ReplayDB ...
+type ReplayDB interface {
+	GetAll(requestID string) ([]*models.Replay, error)
+	Create(requestID string, replay *models.Replay) error
+}
+
+// Stream fans captured requests out to every subscriber of the bin
+type Stream interface {
+	Publish(request *models.Request) error
+	Subscribe(ctx context.Context, binID string) (<-chan *models.Request, error)
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// New configures the database infrastructure
+func New(postgres *gorm.DB) *PostgresRepo {
+	return &PostgresRepo{DB: postgres}
+}
+
+// NewReplays configures the replay database infrastructure
+func NewReplays(postgres *gorm.DB) *ReplayPostgresRepo {
+	return &ReplayPostgresRepo{DB: postgres}
+}
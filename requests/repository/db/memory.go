@@ -0,0 +1,147 @@
+package db
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// MemoryRepo keeps the captured requests in memory. It backs the dev mode, so
+// the requests are lost on restart.
+type MemoryRepo struct {
+	mu       sync.RWMutex
+	requests map[string][]*models.Request
+}
+
+// NewMemory ...
+func NewMemory() *MemoryRepo {
+	return &MemoryRepo{requests: map[string][]*models.Request{}}
+}
+
+// GetAll returns a page of captured requests for the bin, newest first
+func (r *MemoryRepo) GetAll(binID string, opts *gModels.QueryOpts) ([]*models.Request, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	captured := r.requests[binID]
+
+	var requests []*models.Request
+	for i := len(captured) - 1 - opts.GetOffset(); i >= 0 && len(requests) < opts.GetLimit(); i-- {
+		requests = append(requests, copyRequest(captured[i]))
+	}
+
+	return requests, nil
+}
+
+// GetSince returns the requests of the bin captured after the given request,
+// oldest first. Nothing is returned when the request is unknown.
+func (r *MemoryRepo) GetSince(binID string, ID string, limit int) ([]*models.Request, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	captured := r.requests[binID]
+
+	var requests []*models.Request
+	for i, request := range captured {
+		if request.ID != ID {
+			continue
+		}
+		for _, since := range captured[i+1:] {
+			if len(requests) == limit {
+				break
+			}
+			requests = append(requests, copyRequest(since))
+		}
+		break
+	}
+
+	return requests, nil
+}
+
+// Get one captured request associated with the given bin id
+func (r *MemoryRepo) Get(binID string, ID string) (*models.Request, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, request := range r.requests[binID] {
+		if request.ID == ID {
+			return copyRequest(request), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Create stores a new captured request
+func (r *MemoryRepo) Create(binID string, request *models.Request) error {
+	now := time.Now()
+	request.BinID = binID
+	request.ID = uuid.New().String()
+	request.CreatedAt = &now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// requests are kept oldest first, in the order of the postgres index
+	captured := append(r.requests[binID], copyRequest(request))
+	sort.SliceStable(captured, func(i, j int) bool {
+		return captured[i].CreatedAt.Before(*captured[j].CreatedAt)
+	})
+	r.requests[binID] = captured
+
+	return nil
+}
+
+// ReplayMemoryRepo keeps the replays in memory
+type ReplayMemoryRepo struct {
+	mu      sync.RWMutex
+	replays map[string][]*models.Replay
+}
+
+// NewReplaysMemory ...
+func NewReplaysMemory() *ReplayMemoryRepo {
+	return &ReplayMemoryRepo{replays: map[string][]*models.Replay{}}
+}
+
+// GetAll returns every replay of the captured request, newest first
+func (r *ReplayMemoryRepo) GetAll(requestID string) ([]*models.Replay, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.replays[requestID]
+
+	var replays []*models.Replay
+	for i := len(stored) - 1; i >= 0; i-- {
+		replay := *stored[i]
+		replays = append(replays, &replay)
+	}
+
+	return replays, nil
+}
+
+// Create stores a new replay attempt
+func (r *ReplayMemoryRepo) Create(requestID string, replay *models.Replay) error {
+	now := time.Now()
+	replay.RequestID = requestID
+	replay.ID = uuid.New().String()
+	replay.CreatedAt = &now
+
+	stored := *replay
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replays[requestID] = append(r.replays[requestID], &stored)
+	return nil
+}
+
+// copyRequest copies the request so that callers never modify the stored one.
+// Headers and body are never modified after the capture, so they are shared.
+func copyRequest(request *models.Request) *models.Request {
+	copied := *request
+	return &copied
+}
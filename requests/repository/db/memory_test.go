@@ -0,0 +1,62 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+	requestsDB "github.com/hugocortes/hooks-api/requests/repository/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRequests(t *testing.T) {
+	memory := requestsDB.NewMemory()
+	binID := uuid.New().String()
+
+	var created []*models.Request
+	for i := 0; i < 15; i++ {
+		request := &models.Request{Method: "POST", Path: "/"}
+		assert.Nil(t, memory.Create(binID, request))
+		created = append(created, request)
+	}
+	memory.Create(uuid.New().String(), &models.Request{Method: "POST"})
+
+	requests, err := memory.GetAll(binID, &gModels.QueryOpts{Limit: 10, Page: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(requests))
+	assert.Equal(t, created[4].ID, requests[0].ID, "Expected newest first")
+
+	requests, err = memory.GetSince(binID, created[11].ID, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, created[12].ID, requests[0].ID)
+
+	requests, err = memory.GetSince(binID, uuid.New().String(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(requests))
+
+	stored, err := memory.Get(binID, created[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, created[0].ID, stored.ID)
+
+	stored, err = memory.Get(uuid.New().String(), created[0].ID)
+	assert.Nil(t, err)
+	assert.Nil(t, stored, "Expected nil request")
+}
+
+func TestMemoryReplays(t *testing.T) {
+	memory := requestsDB.NewReplaysMemory()
+	requestID := uuid.New().String()
+
+	first := &models.Replay{URL: "http://localhost/first"}
+	second := &models.Replay{URL: "http://localhost/second"}
+	assert.Nil(t, memory.Create(requestID, first))
+	assert.Nil(t, memory.Create(requestID, second))
+
+	replays, err := memory.GetAll(requestID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(replays))
+	assert.Equal(t, second.ID, replays[0].ID, "Expected newest first")
+	assert.Equal(t, requestID, replays[1].RequestID)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"github.com/hugocortes/hooks-api/common/metrics"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+// MetricsRepo counts the requests captured by every bin
+type MetricsRepo struct {
+	requests.DB
+	Metrics *metrics.Metrics
+}
+
+// NewMetrics counts the captures stored in db, or returns db as is when
+// metrics are disabled
+func NewMetrics(db requests.DB, m *metrics.Metrics) requests.DB {
+	if m == nil {
+		return db
+	}
+	return &MetricsRepo{DB: db, Metrics: m}
+}
+
+// Create ...
+func (r *MetricsRepo) Create(binID string, request *models.Request) error {
+	err := r.DB.Create(binID, request)
+	if err == nil {
+		r.Metrics.Capture(binID)
+	}
+
+	return err
+}
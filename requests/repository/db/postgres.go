@@ -0,0 +1,71 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	tableName = "request"
+)
+
+// PostgresRepo provides the database connection
+type PostgresRepo struct {
+	DB *gorm.DB
+}
+
+// GetAll returns a page of captured requests for the bin, newest first
+func (r *PostgresRepo) GetAll(binID string, opts *gModels.QueryOpts) ([]*models.Request, error) {
+	var requests []*models.Request
+
+	table := r.DB.Table(tableName)
+	res := table.Where("bin_id = ?", binID).Order("created_at desc").Offset(opts.GetOffset()).Limit(opts.GetLimit()).Find(&requests)
+
+	return requests, res.Error
+}
+
+// GetSince returns the requests of the bin captured after the given request,
+// oldest first. Nothing is returned when the request is unknown.
+func (r *PostgresRepo) GetSince(binID string, ID string, limit int) ([]*models.Request, error) {
+	var requests []*models.Request
+
+	since, err := r.Get(binID, ID)
+	if err != nil || since == nil {
+		return requests, err
+	}
+
+	table := r.DB.Table(tableName)
+	res := table.Where("bin_id = ? AND (created_at > ? OR (created_at = ? AND id > ?))", binID, since.CreatedAt, since.CreatedAt, since.ID).
+		Order("created_at asc, id asc").Limit(limit).Find(&requests)
+
+	return requests, res.Error
+}
+
+// Get one captured request associated with the given bin id, nil when the
+// bin has no such request
+func (r *PostgresRepo) Get(binID string, ID string) (*models.Request, error) {
+	request := &models.Request{}
+
+	table := r.DB.Table(tableName)
+	err := table.Where("id = ? AND bin_id = ?", ID, binID).First(request).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Create inserts a new captured request to the table
+func (r *PostgresRepo) Create(binID string, request *models.Request) error {
+	request.BinID = binID
+	request.ID = uuid.New().String()
+
+	table := r.DB.Table(tableName)
+
+	return table.Create(&request).Error
+}
@@ -0,0 +1,133 @@
+package db_test
+
+import (
+	"log"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/common/config"
+	"github.com/hugocortes/hooks-api/common/deps"
+	"github.com/hugocortes/hooks-api/migrations"
+	gModels "github.com/hugocortes/hooks-api/models"
+	"github.com/hugocortes/hooks-api/requests/models"
+	requestsDB "github.com/hugocortes/hooks-api/requests/repository/db"
+	"github.com/icrowley/fake"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+var db *gorm.DB
+var testPostgres = &requestsDB.PostgresRepo{}
+
+func testPostgresSetup() {
+	config.LoadEnv("../../../.env")
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Postgres.DB += "-test"
+
+	db = deps.Postgres(cfg)
+	testPostgres = &requestsDB.PostgresRepo{
+		DB: db,
+	}
+
+	migrations.Run(db)
+}
+
+func testPostgresTearDown(binID string) {
+	db.Table("request").Where("bin_id = ?", binID).Delete(&models.Request{})
+	db.Close()
+}
+
+func TestCreateRequest(t *testing.T) {
+	testPostgresSetup()
+	binID := uuid.New().String()
+	defer testPostgresTearDown(binID)
+
+	request := testCreateRequest(binID)
+	assert.NotEqual(t, "", request.ID, "RequestID was not set")
+
+	stored, err := testPostgres.Get(binID, request.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, request.Method, stored.Method)
+	assert.Equal(t, request.Body, stored.Body)
+	assert.Equal(t, "application/json", stored.Headers["Content-Type"][0])
+}
+
+func TestGetRequestOtherBin(t *testing.T) {
+	testPostgresSetup()
+	binID := uuid.New().String()
+	defer testPostgresTearDown(binID)
+
+	request := testCreateRequest(binID)
+
+	stored, err := testPostgres.Get(uuid.New().String(), request.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, stored, "Expected nil request")
+}
+
+func TestRequestErrors(t *testing.T) {
+	testPostgresSetup()
+	binID := uuid.New().String()
+	request := testCreateRequest(binID)
+	testPostgresTearDown(binID)
+
+	requests, err := testPostgres.GetAll(binID, &gModels.QueryOpts{})
+	assert.NotNil(t, err, "Expected the closed connection to fail")
+	assert.Equal(t, 0, len(requests))
+
+	stored, err := testPostgres.Get(binID, request.ID)
+	assert.NotNil(t, err, "Expected the failure not to read as a missing request")
+	assert.Nil(t, stored)
+
+	_, err = testPostgres.GetSince(binID, request.ID, 10)
+	assert.NotNil(t, err)
+}
+
+func TestGetAllRequests(t *testing.T) {
+	testPostgresSetup()
+	binID := uuid.New().String()
+	defer testPostgresTearDown(binID)
+
+	for i := 0; i < 15; i++ {
+		testCreateRequest(binID)
+	}
+
+	opts := &gModels.QueryOpts{Limit: 10, Page: 1}
+	requests, err := testPostgres.GetAll(binID, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(requests))
+}
+
+func TestGetRequestsSince(t *testing.T) {
+	testPostgresSetup()
+	binID := uuid.New().String()
+	defer testPostgresTearDown(binID)
+
+	var created []*models.Request
+	for i := 0; i < 5; i++ {
+		created = append(created, testCreateRequest(binID))
+	}
+
+	requests, err := testPostgres.GetSince(binID, created[1].ID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(requests))
+	assert.Equal(t, created[2].ID, requests[0].ID)
+
+	requests, err = testPostgres.GetSince(binID, uuid.New().String(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(requests))
+}
+
+func testCreateRequest(binID string) *models.Request {
+	request := &models.Request{
+		Method:   "POST",
+		Path:     "/" + fake.Word(),
+		Headers:  models.Headers{"Content-Type": []string{"application/json"}},
+		Body:     []byte(`{"title":"` + fake.ProductName() + `"}`),
+		RemoteIP: fake.IPv4(),
+	}
+	testPostgres.Create(binID, request)
+	return request
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	replayTableName = "replay"
+)
+
+// ReplayPostgresRepo provides the database connection for replays
+type ReplayPostgresRepo struct {
+	DB *gorm.DB
+}
+
+// GetAll returns every replay of the captured request, newest first
+func (r *ReplayPostgresRepo) GetAll(requestID string) ([]*models.Replay, error) {
+	var replays []*models.Replay
+
+	table := r.DB.Table(replayTableName)
+	res := table.Where("request_id = ?", requestID).Order("created_at desc").Find(&replays)
+
+	return replays, res.Error
+}
+
+// Create inserts a new replay attempt to the table
+func (r *ReplayPostgresRepo) Create(requestID string, replay *models.Replay) error {
+	replay.RequestID = requestID
+	replay.ID = uuid.New().String()
+
+	table := r.DB.Table(replayTableName)
+
+	return table.Create(&replay).Error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/metrics"
+	"github.com/hugocortes/hooks-api/requests"
+	"github.com/hugocortes/hooks-api/requests/repository/db"
+	"github.com/hugocortes/hooks-api/requests/repository/stream"
+	"github.com/jinzhu/gorm"
+)
+
+// New ...
+func New(database *gorm.DB, redis *redis.Client, m *metrics.Metrics) *requests.Repository {
+	return &requests.Repository{
+		DB:      db.NewMetrics(db.New(database), m),
+		Replays: db.NewReplays(database),
+		Stream:  stream.New(redis),
+	}
+}
+
+// NewMemory keeps the captured requests and replays in memory
+func NewMemory(m *metrics.Metrics) *requests.Repository {
+	return &requests.Repository{
+		DB:      db.NewMetrics(db.NewMemory(), m),
+		Replays: db.NewReplaysMemory(),
+		Stream:  stream.NewMemory(),
+	}
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// captured requests waiting for a slow subscriber
+	memoryBuffer = 64
+)
+
+// MemoryStream delivers captured requests to the subscribers of a single
+// server instance
+type MemoryStream struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *models.Request]struct{}
+}
+
+// NewMemory ...
+func NewMemory() *MemoryStream {
+	return &MemoryStream{subscribers: map[string]map[chan *models.Request]struct{}{}}
+}
+
+// Publish sends the request to the subscribers of its bin. Subscribers that
+// fall behind miss the request instead of blocking the capture.
+func (s *MemoryStream) Publish(request *models.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.subscribers[request.BinID] {
+		published := *request
+		select {
+		case subscriber <- &published:
+		default:
+			logrus.Warn("dropping stream message for slow subscriber of bin ", request.BinID)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns the requests published to the bin until ctx is done
+func (s *MemoryStream) Subscribe(ctx context.Context, binID string) (<-chan *models.Request, error) {
+	requests := make(chan *models.Request, memoryBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[binID] == nil {
+		s.subscribers[binID] = map[chan *models.Request]struct{}{}
+	}
+	s.subscribers[binID][requests] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subscribers[binID], requests)
+		if len(s.subscribers[binID]) == 0 {
+			delete(s.subscribers, binID)
+		}
+		close(requests)
+	}()
+
+	return requests, nil
+}
@@ -0,0 +1,43 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/hugocortes/hooks-api/requests/repository/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStream(t *testing.T) {
+	memory := stream.NewMemory()
+	binID := uuid.New().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := memory.Subscribe(ctx, binID)
+	assert.Nil(t, err)
+	second, err := memory.Subscribe(context.Background(), binID)
+	assert.Nil(t, err)
+
+	assert.Nil(t, memory.Publish(&models.Request{ID: "other", BinID: uuid.New().String()}))
+	assert.Nil(t, memory.Publish(&models.Request{ID: "captured", BinID: binID}))
+
+	for _, subscriber := range []<-chan *models.Request{first, second} {
+		select {
+		case request := <-subscriber:
+			assert.Equal(t, "captured", request.ID)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the published request")
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-first:
+		assert.False(t, ok, "Expected the cancelled subscription to close")
+	case <-time.After(time.Second):
+		t.Fatal("Expected the cancelled subscription to close")
+	}
+}
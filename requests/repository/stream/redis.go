@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	"github.com/hugocortes/hooks-api/common/cache"
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RedisStream publishes captured requests over redis pub/sub so that every
+// server instance can deliver them to its subscribers
+type RedisStream struct {
+	Client *redis.Client
+}
+
+// New ...
+func New(client *redis.Client) *RedisStream {
+	return &RedisStream{Client: client}
+}
+
+// Publish sends the request to the subscribers of its bin
+func (s *RedisStream) Publish(request *models.Request) error {
+	marshalled, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	return s.Client.Publish(channel(request.BinID), marshalled).Err()
+}
+
+// Subscribe returns the requests published to the bin until ctx is done
+func (s *RedisStream) Subscribe(ctx context.Context, binID string) (<-chan *models.Request, error) {
+	pubsub := s.Client.Subscribe(channel(binID))
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := pubsub.Channel()
+	requests := make(chan *models.Request)
+	go func() {
+		defer close(requests)
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				request := &models.Request{}
+				if err := json.Unmarshal([]byte(message.Payload), request); err != nil {
+					logrus.Warn("dropping malformed stream message: ", err)
+					continue
+				}
+
+				select {
+				case requests <- request:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return requests, nil
+}
+
+func channel(binID string) string {
+	return cache.GenKey("Stream", binID)
+}
@@ -0,0 +1,198 @@
+package signatures
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+var errMismatch = errors.New("signature mismatch")
+
+// GitHub verifies X-Hub-Signature-256
+type GitHub struct{}
+
+// Verify ...
+func (v *GitHub) Verify(request *models.Request, options *Options) error {
+	signature := header(request, "X-Hub-Signature-256")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	if !equal("sha256="+hexHMAC([]byte(options.Secret), request.Body), signature) {
+		return errMismatch
+	}
+	return nil
+}
+
+// Stripe verifies the v1 signatures and timestamp of Stripe-Signature
+type Stripe struct{}
+
+// Verify ...
+func (v *Stripe) Verify(request *models.Request, options *Options) error {
+	signature := header(request, "Stripe-Signature")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signature, ",") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		switch pair[0] {
+		case "t":
+			timestamp = pair[1]
+		case "v1":
+			signatures = append(signatures, pair[1])
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return errors.New("malformed Stripe-Signature")
+	}
+
+	expected := hexHMAC([]byte(options.Secret), []byte(timestamp+"."), request.Body)
+	for _, actual := range signatures {
+		if equal(expected, actual) {
+			return fresh(timestamp, options)
+		}
+	}
+	return errMismatch
+}
+
+// Slack verifies the v0 X-Slack-Signature and X-Slack-Request-Timestamp
+type Slack struct{}
+
+// Verify ...
+func (v *Slack) Verify(request *models.Request, options *Options) error {
+	signature := header(request, "X-Slack-Signature")
+	timestamp := header(request, "X-Slack-Request-Timestamp")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	expected := "v0=" + hexHMAC([]byte(options.Secret), []byte("v0:"+timestamp+":"), request.Body)
+	if !equal(expected, signature) {
+		return errMismatch
+	}
+	return fresh(timestamp, options)
+}
+
+// Shopify verifies X-Shopify-Hmac-Sha256
+type Shopify struct{}
+
+// Verify ...
+func (v *Shopify) Verify(request *models.Request, options *Options) error {
+	signature := header(request, "X-Shopify-Hmac-Sha256")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	mac := hmac.New(sha256.New, []byte(options.Secret))
+	mac.Write(request.Body)
+	if !equal(base64.StdEncoding.EncodeToString(mac.Sum(nil)), signature) {
+		return errMismatch
+	}
+	return nil
+}
+
+// Twilio verifies X-Twilio-Signature over the full url and, for form posts,
+// the sorted form parameters. JSON bodies are covered by the bodySHA256 query
+// parameter instead.
+type Twilio struct{}
+
+// Verify ...
+func (v *Twilio) Verify(request *models.Request, options *Options) error {
+	signature := header(request, "X-Twilio-Signature")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	payload := request.URL
+	if strings.HasPrefix(header(request, "Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(request.Body))
+		if err != nil {
+			return errors.New("malformed form body")
+		}
+
+		keys := make([]string, 0, len(form))
+		for key := range form {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range form[key] {
+				payload += key + value
+			}
+		}
+	} else if target, err := url.Parse(request.URL); err == nil {
+		if bodySHA := target.Query().Get("bodySHA256"); bodySHA != "" {
+			sum := sha256.Sum256(request.Body)
+			if !equal(hex.EncodeToString(sum[:]), bodySHA) {
+				return errors.New("bodySHA256 mismatch")
+			}
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(options.Secret))
+	mac.Write([]byte(payload))
+	if !equal(base64.StdEncoding.EncodeToString(mac.Sum(nil)), signature) {
+		return errMismatch
+	}
+	return nil
+}
+
+// StandardWebhooks verifies the webhook-signature header of the Standard
+// Webhooks specification. Secrets may keep their whsec_ prefix.
+type StandardWebhooks struct{}
+
+// Verify ...
+func (v *StandardWebhooks) Verify(request *models.Request, options *Options) error {
+	ID := header(request, "Webhook-Id")
+	timestamp := header(request, "Webhook-Timestamp")
+	signature := header(request, "Webhook-Signature")
+	if signature == "" {
+		return ErrMissing
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(options.Secret, "whsec_"))
+	if err != nil {
+		return errors.New("secret is not base64 encoded")
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ID + "." + timestamp + "."))
+	mac.Write(request.Body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	for _, versioned := range strings.Fields(signature) {
+		pair := strings.SplitN(versioned, ",", 2)
+		if len(pair) == 2 && pair[0] == "v1" && equal(expected, pair[1]) {
+			return fresh(timestamp, options)
+		}
+	}
+	return errMismatch
+}
+
+func header(request *models.Request, key string) string {
+	return http.Header(request.Headers).Get(key)
+}
+
+// hexHMAC returns the hex encoded HMAC-SHA256 of the concatenated parts
+func hexHMAC(key []byte, parts ...[]byte) string {
+	mac := hmac.New(sha256.New, key)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
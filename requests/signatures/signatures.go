@@ -0,0 +1,99 @@
+// Package signatures verifies the signatures webhook providers attach to the
+// requests they deliver
+package signatures
+
+import (
+	"crypto/hmac"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hugocortes/hooks-api/requests/models"
+)
+
+const (
+	// DefaultTolerance is the accepted age of signed timestamps
+	DefaultTolerance = 5 * time.Minute
+)
+
+// ErrMissing is returned when the request carries no signature at all
+var ErrMissing = errors.New("signature missing")
+
+// Options are the inputs of a verification besides the request
+type Options struct {
+	Secret    string
+	Tolerance time.Duration
+	Now       time.Time
+}
+
+// Verifier checks the signature of one provider. A nil error means the
+// signature is valid.
+type Verifier interface {
+	Verify(request *models.Request, options *Options) error
+}
+
+// providers verifies the signatures of every supported provider
+var providers = map[string]Verifier{
+	"github":            &GitHub{},
+	"stripe":            &Stripe{},
+	"slack":             &Slack{},
+	"shopify":           &Shopify{},
+	"twilio":            &Twilio{},
+	"standard-webhooks": &StandardWebhooks{},
+}
+
+// Get returns the verifier of the provider
+func Get(name string) (Verifier, bool) {
+	verifier, ok := providers[name]
+	return verifier, ok
+}
+
+// Names returns the providers in order
+func Names() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Verdict runs the verifier and returns the verdict and its reason
+func Verdict(verifier Verifier, request *models.Request, options *Options) (string, string) {
+	err := verifier.Verify(request, options)
+	switch {
+	case err == nil:
+		return models.VerdictVerified, ""
+	case err == ErrMissing:
+		return models.VerdictMissing, err.Error()
+	default:
+		return models.VerdictFailed, err.Error()
+	}
+}
+
+// fresh checks the unix timestamp is within the tolerance of options.Now
+func fresh(timestamp string, options *Options) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid signature timestamp")
+	}
+
+	tolerance := options.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	age := options.Now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+
+	return nil
+}
+
+// equal compares signatures in constant time
+func equal(expected string, actual string) bool {
+	return hmac.Equal([]byte(expected), []byte(actual))
+}
@@ -0,0 +1,159 @@
+package signatures_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hugocortes/hooks-api/requests/models"
+	"github.com/hugocortes/hooks-api/requests/signatures"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	secret  = "shhh"
+	payload = `{"id":"evt_1","type":"ping"}`
+)
+
+var now = time.Unix(1614265330, 0)
+
+func mac(key string, parts ...string) []byte {
+	m := hmac.New(sha256.New, []byte(key))
+	for _, part := range parts {
+		m.Write([]byte(part))
+	}
+	return m.Sum(nil)
+}
+
+func request(body string, headers ...string) *models.Request {
+	header := http.Header{}
+	for i := 0; i < len(headers); i += 2 {
+		header.Set(headers[i], headers[i+1])
+	}
+	return &models.Request{Method: "POST", Headers: models.Headers(header), Body: []byte(body)}
+}
+
+func TestVerdict(t *testing.T) {
+	stale := "1614260000"
+	twilio := hmac.New(sha1.New, []byte(secret))
+	twilio.Write([]byte("https://hooks.example.com/b/1?x=1" + "Body" + "hi" + "From" + "+1555"))
+	twilioRequest := request("From=%2B1555&Body=hi",
+		"Content-Type", "application/x-www-form-urlencoded",
+		"X-Twilio-Signature", base64.StdEncoding.EncodeToString(twilio.Sum(nil)),
+	)
+	twilioRequest.URL = "https://hooks.example.com/b/1?x=1"
+
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		request  *models.Request
+		verdict  string
+		reason   string
+	}{
+		{
+			name:     "github documented sample",
+			provider: "github",
+			secret:   "It's a Secret to Everybody",
+			request:  request("Hello, World!", "X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"),
+			verdict:  models.VerdictVerified,
+		},
+		{
+			name:     "github wrong secret",
+			provider: "github",
+			secret:   "nope",
+			request:  request("Hello, World!", "X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"),
+			verdict:  models.VerdictFailed,
+			reason:   "signature mismatch",
+		},
+		{
+			name:     "github missing",
+			provider: "github",
+			secret:   secret,
+			request:  request(payload),
+			verdict:  models.VerdictMissing,
+			reason:   "signature missing",
+		},
+		{
+			name:     "stripe",
+			provider: "stripe",
+			secret:   secret,
+			request:  request(payload, "Stripe-Signature", "t=1614265330,v1=deadbeef,v1="+hex.EncodeToString(mac(secret, "1614265330.", payload))),
+			verdict:  models.VerdictVerified,
+		},
+		{
+			name:     "stripe replayed",
+			provider: "stripe",
+			secret:   secret,
+			request:  request(payload, "Stripe-Signature", "t="+stale+",v1="+hex.EncodeToString(mac(secret, stale+".", payload))),
+			verdict:  models.VerdictFailed,
+			reason:   "signature timestamp outside tolerance",
+		},
+		{
+			name:     "slack",
+			provider: "slack",
+			secret:   secret,
+			request: request(payload,
+				"X-Slack-Request-Timestamp", "1614265330",
+				"X-Slack-Signature", "v0="+hex.EncodeToString(mac(secret, "v0:1614265330:", payload)),
+			),
+			verdict: models.VerdictVerified,
+		},
+		{
+			name:     "shopify",
+			provider: "shopify",
+			secret:   secret,
+			request:  request(payload, "X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac(secret, payload))),
+			verdict:  models.VerdictVerified,
+		},
+		{
+			name:     "shopify tampered body",
+			provider: "shopify",
+			secret:   secret,
+			request:  request(payload+" ", "X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac(secret, payload))),
+			verdict:  models.VerdictFailed,
+			reason:   "signature mismatch",
+		},
+		{
+			name:     "twilio form",
+			provider: "twilio",
+			secret:   secret,
+			request:  twilioRequest,
+			verdict:  models.VerdictVerified,
+		},
+		{
+			name:     "standard webhooks specification sample",
+			provider: "standard-webhooks",
+			secret:   "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+			request: request(`{"test": 2432232314}`,
+				"Webhook-Id", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+				"Webhook-Timestamp", "1614265330",
+				"Webhook-Signature", "v1,bm9ldHUjKzFob2xhbWFoaWZ1 v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=",
+			),
+			verdict: models.VerdictVerified,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier, ok := signatures.Get(test.provider)
+			assert.True(t, ok)
+
+			verdict, reason := signatures.Verdict(verifier, test.request, &signatures.Options{
+				Secret: test.secret,
+				Now:    now,
+			})
+			assert.Equal(t, test.verdict, verdict)
+			assert.Equal(t, test.reason, reason)
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"github", "shopify", "slack", "standard-webhooks", "stripe", "twilio"}, signatures.Names())
+}